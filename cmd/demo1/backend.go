@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Backend 是一个存储引擎，负责连接、建表/索引、数据加载与基础的 Sum 查询
+// Name 与 -mode 中的名称及 Result.Engine 一致（如 mysql, es）
+type Backend interface {
+	Name() string
+	Open() error
+	// Exists 判断 customer_orders 是否已有数据；返回 error 表示已有数据不能用于本次测试
+	Exists() (bool, error)
+	// InitSchema 创建表/索引，-reload 时删除并重建
+	InitSchema() error
+	Load(batch []Order) error
+	// Sum 计算按 id 升序前 limit 条的 amount 总和（limit 为 0 表示全量）
+	Sum(limit int) Result
+	Close() error
+}
+
+// loadFinisher 由数据加载完成后需要额外处理的后端实现（如 ES refresh）
+type loadFinisher interface {
+	FinishLoad() error
+}
+
+// loadStarter 由数据加载开始前需要准备的后端实现（如 ES 写入调优）
+type loadStarter interface {
+	StartLoad() error
+}
+
+// asyncLoader 由可以异步写入的后端实现（如 ES BulkProcessor）
+// AsyncLoad 在 StartLoad 之后调用，返回 true 表示本次加载为异步写入：Load 只负责入队，
+// 写入/失败/重试行数与每个请求的耗时由后端在请求完成时记入 st；FlushLoad 等待所有已入队的数据写完
+type asyncLoader interface {
+	AsyncLoad(st *loadStats) (bool, error)
+	FlushLoad() error
+}
+
+// loadRetrier 由能区分临时错误的后端实现，Retryable 返回 true 的 Load 错误会整批重试
+// 未实现时 Load 返回的错误都视为永久失败；部分失败通过 *bulkError 返回
+type loadRetrier interface {
+	Retryable(err error) bool
+}
+
+// loadMethoder 由支持多种写入方式的后端实现，返回本次使用的写入方式（用于写入汇总与 load 场景）
+type loadMethoder interface {
+	LoadMethod() string
+}
+
+// -mode all 对应的后端
+var defaultBackends = []string{"mysql", "es"}
+
+var backendRegistry = map[string]Backend{}
+
+func registerBackend(b Backend) {
+	if _, ok := backendRegistry[b.Name()]; ok {
+		panic("duplicate backend: " + b.Name())
+	}
+	backendRegistry[b.Name()] = b
+}
+
+func backendNames() []string {
+	names := make([]string, 0, len(backendRegistry))
+	for name := range backendRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseModes 解析 -mode：逗号分隔的后端列表，all 展开为默认后端，重复项只保留一次
+func parseModes(mode string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(mode, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		expanded := []string{name}
+		if name == "all" {
+			expanded = defaultBackends
+		}
+		for _, n := range expanded {
+			if n != "" && !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
+// openBackends 按 -mode 顺序连接所有启用的后端
+func openBackends() []Backend {
+	var backends []Backend
+	for _, name := range cfg.Backends {
+		b, ok := backendRegistry[name]
+		if !ok {
+			log.Fatalf("Invalid -mode: unknown backend %s (supported: all, %s)", name, strings.Join(backendNames(), ", "))
+		}
+		if err := b.Open(); err != nil {
+			log.Fatalf("%s connect failed: %v", engineLabel(name), err)
+		}
+		backends = append(backends, b)
+	}
+	return backends
+}
+
+func closeBackends(backends []Backend) {
+	for _, b := range backends {
+		if err := b.Close(); err != nil {
+			log.Printf("%s close failed: %v", engineLabel(b.Name()), err)
+		}
+	}
+}
+
+// --- 通用 Sum 场景: 直接执行后端的 Sum，新增后端时在其 init 中注册即可 ---
+type backendSumScenario struct {
+	backend string
+	b       Backend
+}
+
+func (s *backendSumScenario) Name() string      { return s.backend + "-sum" }
+func (s *backendSumScenario) Engines() []string { return []string{s.backend} }
+func (s *backendSumScenario) Description() string {
+	return fmt.Sprintf("%s SUM（ORDER BY id LIMIT n，后端 Sum 实现）", engineLabel(s.backend))
+}
+
+func (s *backendSumScenario) Prepare(env *Env) error {
+	s.b = env.Backend(s.backend)
+	if s.b == nil {
+		return skipScenario(fmt.Sprintf("后端 %s 未启用", s.backend))
+	}
+	return nil
+}
+
+func (s *backendSumScenario) Run(limit int) Result {
+	res := s.b.Sum(limit)
+	res.Scenario = s.Name()
+	return res
+}
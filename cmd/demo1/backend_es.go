@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- Elasticsearch 后端 ---
+type esBackend struct {
+	client *elastic.Client
+	writer *esBulkWriter    // -es-writer processor 时在 AsyncLoad 中创建
+	tuning *esIndexSettings // -es-load-tuning 时保存的原设置，FinishLoad 中恢复
+}
+
+func init() {
+	registerBackend(&esBackend{})
+}
+
+func (b *esBackend) Name() string { return "es" }
+
+func (b *esBackend) Open() error {
+	esClientOpts := []elastic.ClientOptionFunc{
+		elastic.SetURL(cfg.ESUrl),
+		elastic.SetSniff(false), // 单节点建议关闭
+	}
+	// 如果提供了用户名和密码，添加认证
+	if cfg.ESUser != "" && cfg.ESPassword != "" {
+		esClientOpts = append(esClientOpts, elastic.SetBasicAuth(cfg.ESUser, cfg.ESPassword))
+	}
+	client, err := elastic.NewClient(esClientOpts...)
+	if err != nil {
+		return err
+	}
+	b.client = client
+	fmt.Println(">>> Elasticsearch 连接成功")
+	return nil
+}
+
+func (b *esBackend) Close() error {
+	b.client.Stop()
+	return nil
+}
+
+func (b *esBackend) Exists() (bool, error) {
+	ctx := context.Background()
+	// 先检查索引是否存在
+	exists, err := b.client.IndexExists("customer_orders").Do(ctx)
+	if err != nil {
+		fmt.Printf(">>> [检查数据] Elasticsearch 检查索引出错: %v\n", err)
+		return false, nil
+	}
+	if !exists {
+		fmt.Println(">>> [检查数据] Elasticsearch 索引 customer_orders 不存在")
+		return false, nil
+	}
+	// 索引存在，再检查数据量
+	count, err := b.client.Count("customer_orders").Do(ctx)
+	if err != nil {
+		fmt.Printf(">>> [检查数据] Elasticsearch 统计数据出错: %v\n", err)
+		return false, nil
+	}
+	if count == 0 {
+		fmt.Println(">>> [检查数据] Elasticsearch 索引 customer_orders 存在，但数据为空")
+		return false, nil
+	}
+	fmt.Printf(">>> [检查数据] Elasticsearch 索引 customer_orders 已存在，当前数据量: %d 条\n", count)
+	// 已有索引的 amount 存储策略必须与本次配置一致，否则测试结果没有意义
+	amountType, err := esCurrentAmountType(b.client)
+	if err != nil {
+		fmt.Printf(">>> [检查数据] Elasticsearch 读取 amount mapping 出错: %v\n", err)
+	} else if amountType != cfg.ESAmountType {
+		return true, fmt.Errorf("ES index amount mapping is %s but -es-amount-type=%s, use -reload to rebuild the index", amountType, cfg.ESAmountType)
+	}
+	return true, nil
+}
+
+func (b *esBackend) InitSchema() error {
+	// ES Mapping:
+	// 1. 设置 max_result_window 为 1000w
+	// 2. amount 字段按 -es-amount-type 选择存储策略
+	// 注意：只有在 reload 模式下才会删除重建索引
+	fmt.Printf(">>> Elasticsearch amount 存储策略: %s\n", cfg.ESAmountType)
+	mapping := esIndexMapping(cfg.ESAmountType)
+	ctx := context.Background()
+	exists, _ := b.client.IndexExists("customer_orders").Do(ctx)
+	if exists {
+		// 索引已存在
+		if !cfg.Reload {
+			fmt.Println(">>> Elasticsearch 索引初始化完成（索引已存在，保留现有数据）")
+			return nil
+		}
+		// reload 模式下才删除重建
+		fmt.Println(">>> Elasticsearch 检测到索引存在，由于启用了 reload 参数，将删除并重建索引")
+		b.client.DeleteIndex("customer_orders").Do(ctx)
+		if _, err := b.client.CreateIndex("customer_orders").BodyString(mapping).Do(ctx); err != nil {
+			return fmt.Errorf("create index: %w", err)
+		}
+		fmt.Println(">>> Elasticsearch 索引重建完成")
+		return nil
+	}
+	// 索引不存在，创建新索引
+	if _, err := b.client.CreateIndex("customer_orders").BodyString(mapping).Do(ctx); err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+	fmt.Println(">>> Elasticsearch 索引初始化完成（新建索引）")
+	return nil
+}
+
+// esIndexMapping 返回 customer_orders 索引的 settings 与 mappings（-export-dir 导出的 es_mapping.json 也使用该内容）
+func esIndexMapping(amountType string) string {
+	return fmt.Sprintf(`{
+	"settings": {
+		"number_of_shards": 3,
+		"number_of_replicas": 0,
+		"max_result_window": 10000000
+	},
+	"mappings": {
+		"properties": {
+			"id": { "type": "long" },
+			"order_id": { "type": "keyword" },
+			"customer_id": { "type": "keyword" },
+			"amount": %s,
+			"create_time": { "type": "date_nanos", "format": "yyyy-MM-dd HH:mm:ss.SSSSSS" }
+		}
+	}
+}`, esAmountMapping(amountType))
+}
+
+// StartLoad 按需应用写入调优
+func (b *esBackend) StartLoad() error {
+	if cfg.ESLoadTuning {
+		saved, err := esApplyLoadTuning(b.client)
+		if err != nil {
+			return err
+		}
+		b.tuning = saved
+	}
+	return nil
+}
+
+// AsyncLoad 在 -es-writer processor 时启动 BulkProcessor，写入结果与请求耗时在每次提交完成时记入 st
+func (b *esBackend) AsyncLoad(st *loadStats) (bool, error) {
+	if cfg.ESWriter != esWriterProcessor {
+		return false, nil
+	}
+	w, err := newESBulkWriter(b.client, st)
+	if err != nil {
+		return false, err
+	}
+	b.writer = w
+	fmt.Printf(">>> Elasticsearch 使用 BulkProcessor 写入: workers=%d, bulk_actions=%d, bulk_size=%d, flush_interval=%v\n",
+		cfg.ESBulkWorkers, cfg.ESBulkActions, cfg.ESBulkSize, cfg.ESFlushInterval)
+	return true, nil
+}
+
+// Load 使用 bulk 写入一批订单，以订单 id 作为 _id，重试时不会产生重复文档
+// bulk 请求本身成功时仍需逐项检查响应：被拒绝的文档（如 429、mapping 错误）通过 *bulkError 返回
+// 使用 BulkProcessor 时只负责入队，写入结果由 esBulkWriter 在提交完成时记录
+func (b *esBackend) Load(orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	if b.writer != nil {
+		for _, o := range orders {
+			b.writer.add(o)
+		}
+		return nil
+	}
+	bulk := b.client.Bulk().Index("customer_orders")
+	for _, o := range orders {
+		bulk.Add(elastic.NewBulkIndexRequest().Id(strconv.FormatInt(o.ID, 10)).Doc(esDocument(o, cfg.ESAmountType)))
+	}
+	resp, err := bulk.Do(context.Background())
+	if err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+	// 响应中的 items 与请求顺序一一对应
+	// cause 优先记录永久失败项的错误，全部可重试时记录第一个可重试项
+	be := &bulkError{}
+	var retryCause error
+	for i, item := range resp.Items {
+		for _, res := range item {
+			if res.Error == nil && res.Status < 300 {
+				continue
+			}
+			err := fmt.Errorf("status %d: %s", res.Status, esItemError(res.Error))
+			if esRetryableStatus(res.Status) {
+				be.retry = append(be.retry, orders[i])
+				if retryCause == nil {
+					retryCause = err
+				}
+			} else {
+				be.permanent++
+				if be.cause == nil {
+					be.cause = err
+				}
+			}
+		}
+	}
+	if be.cause == nil {
+		be.cause = retryCause
+	}
+	if be.cause == nil {
+		return nil
+	}
+	return be
+}
+
+// esRetryableStatus 判断 bulk 单项或请求的状态码是否可重试（队列已满、节点暂不可用等）
+func esRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func esItemError(e *elastic.ErrorDetails) string {
+	if e == nil {
+		return "unknown error"
+	}
+	return e.Type + ": " + e.Reason
+}
+
+// Retryable 判断整个 bulk 请求的错误是否可重试：连接错误、超时与 429/5xx
+func (b *esBackend) Retryable(err error) bool {
+	if elastic.IsConnErr(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var esErr *elastic.Error
+	if errors.As(err, &esErr) {
+		return esRetryableStatus(esErr.Status)
+	}
+	return false
+}
+
+func (b *esBackend) LoadMethod() string { return cfg.ESWriter }
+
+// FlushLoad 等待 BulkProcessor 写完所有文档（含重试）并关闭
+func (b *esBackend) FlushLoad() error {
+	if b.writer == nil {
+		return nil
+	}
+	w := b.writer
+	b.writer = nil
+	return w.close()
+}
+
+// FinishLoad 恢复写入调优前的索引设置，并强制刷新 ES，确保数据立即可查
+func (b *esBackend) FinishLoad() error {
+	if b.tuning != nil {
+		saved := b.tuning
+		b.tuning = nil
+		if err := saved.restore(b.client); err != nil {
+			return err
+		}
+	}
+	_, err := b.client.Refresh("customer_orders").Do(context.Background())
+	return err
+}
+
+// Sum 使用 BigDecimal scripted_metric（与场景 C 相同），保证与其他后端精确可比
+func (b *esBackend) Sum(limit int) Result {
+	return benchmarkESScriptAgg(b.client, limit)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// --- MySQL 后端 ---
+type mysqlBackend struct {
+	db *sql.DB
+
+	mu   sync.Mutex
+	stmt *sql.Stmt // -mysql-load-method prepared 时复用的多行 INSERT
+}
+
+func init() {
+	registerBackend(&mysqlBackend{})
+}
+
+// MySQL DDL: 使用 DECIMAL 保证金额精确, DATETIME(6) 保证微秒精度（-export-dir 导出的 load_mysql.sql 也使用该语句）
+const mysqlCreateTable = `CREATE TABLE IF NOT EXISTS customer_orders (
+	id BIGINT AUTO_INCREMENT UNIQUE,
+	order_id VARCHAR(64) PRIMARY KEY,
+	customer_id VARCHAR(64),
+	amount DECIMAL(19, 9),
+	create_time DATETIME(6),
+	KEY idx_id (id),
+	KEY idx_create_time (create_time),
+	KEY idx_amt (amount)
+)`
+
+func (b *mysqlBackend) Name() string { return "mysql" }
+
+func (b *mysqlBackend) Open() error {
+	db, err := sql.Open("mysql", cfg.MySQLDSN)
+	if err != nil {
+		return err
+	}
+	// 并发压测时连接池需容纳所有客户端，数据加载时需容纳所有消费者
+	maxConns := 20
+	if cfg.Clients > maxConns {
+		maxConns = cfg.Clients
+	}
+	if w := loadWorkers(b.Name()); w > maxConns {
+		maxConns = w
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns / 2)
+	b.db = db
+	fmt.Println(">>> MySQL 连接成功")
+	return nil
+}
+
+func (b *mysqlBackend) Close() error {
+	if b.stmt != nil {
+		b.stmt.Close()
+	}
+	return b.db.Close()
+}
+
+func (b *mysqlBackend) Exists() (bool, error) {
+	// 先检查表是否存在（通过 information_schema）
+	var tableExists int
+	err := b.db.QueryRow(`
+			SELECT COUNT(*) FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'customer_orders'
+		`).Scan(&tableExists)
+
+	if err != nil {
+		fmt.Printf(">>> [检查数据] MySQL 检查表出错: %v\n", err)
+		return false, nil
+	}
+	if tableExists == 0 {
+		fmt.Println(">>> [检查数据] MySQL 表 customer_orders 不存在")
+		return false, nil
+	}
+	// 表存在，再检查数据量
+	var count int
+	if err := b.db.QueryRow("SELECT COUNT(*) FROM customer_orders").Scan(&count); err != nil {
+		fmt.Printf(">>> [检查数据] MySQL 统计数据出错: %v\n", err)
+		return false, nil
+	}
+	if count == 0 {
+		fmt.Println(">>> [检查数据] MySQL 表 customer_orders 存在，但数据为空")
+		return false, nil
+	}
+	fmt.Printf(">>> [检查数据] MySQL 表 customer_orders 已存在，当前数据量: %d 条\n", count)
+	return true, nil
+}
+
+func (b *mysqlBackend) InitSchema() error {
+	// 如果是 reload 模式，先删除表以保证 schema 更新
+	if cfg.Reload {
+		fmt.Println(">>> MySQL: 由于启用了 reload 参数，将删除并重建表 customer_orders")
+		if _, err := b.db.Exec(`DROP TABLE IF EXISTS customer_orders`); err != nil {
+			return fmt.Errorf("drop table: %w", err)
+		}
+	}
+
+	// 如果非 reload 模式，CREATE TABLE IF NOT EXISTS 不会删除现有数据
+	_, err := b.db.Exec(mysqlCreateTable)
+	if err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+	if cfg.Reload {
+		fmt.Println(">>> MySQL 表重建完成")
+	} else {
+		fmt.Println(">>> MySQL 表初始化完成（如果表已存在则保留现有数据）")
+	}
+	return nil
+}
+
+// Load 按 -mysql-load-method 写入一批订单
+func (b *mysqlBackend) Load(orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	switch cfg.MySQLLoadMethod {
+	case mysqlLoadPrepared:
+		return b.loadPrepared(orders)
+	case mysqlLoadTx:
+		return b.loadTx(orders)
+	case mysqlLoadData:
+		return b.loadDataInfile(orders)
+	default:
+		return b.loadMulti(orders)
+	}
+}
+
+func (b *mysqlBackend) LoadMethod() string { return cfg.MySQLLoadMethod }
+
+// Retryable 判断写入错误是否可重试：连接断开、死锁、锁等待超时与连接数过多
+// 主键冲突等数据错误不重试
+func (b *mysqlBackend) Retryable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1040, 1053, 1205, 1213: // too many connections, server shutdown, lock wait timeout, deadlock
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (b *mysqlBackend) Sum(limit int) Result {
+	return benchmarkMySQL(b.db, limit)
+}
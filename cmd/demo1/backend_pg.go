@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// --- PostgreSQL 后端 ---
+// amount 使用 NUMERIC(19,9)，create_time 使用 timestamp(6)，数据通过 COPY 批量加载
+type pgBackend struct {
+	pool *pgxpool.Pool
+}
+
+var pgCopyColumns = []string{"id", "order_id", "customer_id", "amount", "create_time"}
+
+func init() {
+	registerBackend(&pgBackend{})
+	registerScenario(&backendSumScenario{backend: "pg"})
+	engineLabels["pg"] = "PG"
+}
+
+func (b *pgBackend) Name() string { return "pg" }
+
+func (b *pgBackend) Open() error {
+	poolCfg, err := pgxpool.ParseConfig(cfg.PGDSN)
+	if err != nil {
+		return err
+	}
+	// 并发压测时连接池需容纳所有客户端，数据加载时需容纳所有消费者
+	maxConns := 20
+	if cfg.Clients > maxConns {
+		maxConns = cfg.Clients
+	}
+	if w := loadWorkers(b.Name()); w > maxConns {
+		maxConns = w
+	}
+	poolCfg.MaxConns = int32(maxConns)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return err
+	}
+	b.pool = pool
+	fmt.Println(">>> PostgreSQL 连接成功")
+	return nil
+}
+
+func (b *pgBackend) Close() error {
+	b.pool.Close()
+	return nil
+}
+
+func (b *pgBackend) Exists() (bool, error) {
+	ctx := context.Background()
+	var tableExists bool
+	if err := b.pool.QueryRow(ctx, "SELECT to_regclass('customer_orders') IS NOT NULL").Scan(&tableExists); err != nil {
+		fmt.Printf(">>> [检查数据] PostgreSQL 检查表出错: %v\n", err)
+		return false, nil
+	}
+	if !tableExists {
+		fmt.Println(">>> [检查数据] PostgreSQL 表 customer_orders 不存在")
+		return false, nil
+	}
+	var count int64
+	if err := b.pool.QueryRow(ctx, "SELECT COUNT(*) FROM customer_orders").Scan(&count); err != nil {
+		fmt.Printf(">>> [检查数据] PostgreSQL 统计数据出错: %v\n", err)
+		return false, nil
+	}
+	if count == 0 {
+		fmt.Println(">>> [检查数据] PostgreSQL 表 customer_orders 存在，但数据为空")
+		return false, nil
+	}
+	fmt.Printf(">>> [检查数据] PostgreSQL 表 customer_orders 已存在，当前数据量: %d 条\n", count)
+	return true, nil
+}
+
+func (b *pgBackend) InitSchema() error {
+	ctx := context.Background()
+	if cfg.Reload {
+		fmt.Println(">>> PostgreSQL: 由于启用了 reload 参数，将删除并重建表 customer_orders")
+		if _, err := b.pool.Exec(ctx, "DROP TABLE IF EXISTS customer_orders"); err != nil {
+			return fmt.Errorf("drop table: %w", err)
+		}
+	}
+
+	// NUMERIC(19,9) 与 MySQL DECIMAL(19,9) 精度一致，timestamp(6) 保证微秒精度
+	ddl := []string{
+		`CREATE TABLE IF NOT EXISTS customer_orders (
+			id BIGINT NOT NULL UNIQUE,
+			order_id VARCHAR(64) PRIMARY KEY,
+			customer_id VARCHAR(64),
+			amount NUMERIC(19, 9),
+			create_time TIMESTAMP(6)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_create_time ON customer_orders (create_time)",
+		"CREATE INDEX IF NOT EXISTS idx_amt ON customer_orders (amount)",
+	}
+	for _, stmt := range ddl {
+		if _, err := b.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("create table: %w", err)
+		}
+	}
+	if cfg.Reload {
+		fmt.Println(">>> PostgreSQL 表重建完成")
+	} else {
+		fmt.Println(">>> PostgreSQL 表初始化完成（如果表已存在则保留现有数据）")
+	}
+	return nil
+}
+
+// Load 使用 COPY FROM STDIN（二进制协议）写入一批订单
+func (b *pgBackend) Load(orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	rows := make([][]interface{}, 0, len(orders))
+	for _, o := range orders {
+		var amount pgtype.Numeric
+		if err := amount.Scan(o.Amount); err != nil {
+			return fmt.Errorf("order %d: invalid amount %q: %w", o.ID, o.Amount, err)
+		}
+		createTime, err := time.Parse(createTimeLayout, o.CreateTime)
+		if err != nil {
+			return fmt.Errorf("order %d: invalid create_time %q: %w", o.ID, o.CreateTime, err)
+		}
+		rows = append(rows, []interface{}{o.ID, o.OrderID, o.CustomerID, amount, createTime})
+	}
+	_, err := b.pool.CopyFrom(context.Background(), pgx.Identifier{"customer_orders"}, pgCopyColumns, pgx.CopyFromRows(rows))
+	return err
+}
+
+// Retryable 判断 COPY 错误是否可重试：未发送成功的请求、超时、连接异常（08）、
+// 事务冲突（40）与资源不足（53）；COPY 在单个事务内执行，失败时整批回滚可以安全重试
+func (b *pgBackend) Retryable(err error) bool {
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code[:2] {
+		case "08", "40", "53":
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (b *pgBackend) Sum(limit int) Result {
+	ctx := context.Background()
+	start := time.Now()
+	res := Result{Scenario: "pg-sum", Query: querySumTopN, Engine: "pg", Type: "PGSum", Limit: limit}
+	var sumStr *string
+	var err error
+
+	if limit == 0 {
+		// 全量：NUMERIC 精确求和，转为 TEXT 保留精度
+		err = b.pool.QueryRow(ctx, "SELECT CAST(SUM(amount) AS TEXT), COUNT(*) FROM customer_orders").Scan(&sumStr, &res.Rows)
+	} else {
+		// 部分数据：子查询先按 id 排序截取，再聚合
+		err = b.pool.QueryRow(ctx, `
+			SELECT CAST(SUM(amount) AS TEXT), COUNT(*) FROM
+			(SELECT amount FROM customer_orders ORDER BY id ASC LIMIT $1) AS subquery
+		`, limit).Scan(&sumStr, &res.Rows)
+	}
+	res.Duration = time.Since(start)
+
+	if err != nil {
+		res.Error = fmt.Sprintf("PostgreSQL Query Error: %v", err)
+		return res
+	}
+
+	res.Sum = "0"
+	if sumStr != nil {
+		res.Sum = *sumStr
+	}
+	return res
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// --- SQLite 后端（纯 Go 驱动，无需外部服务） ---
+// SQLite 没有定点小数类型，amount 以原始文本保存，另外拆分为整数部分 amount_int 与 9 位小数部分 amount_nanos
+// 两列分别用整数 SUM 求和，再在客户端合并为精确结果（单列 amount*1e9 在百万行时会超出 int64）
+type sqliteBackend struct {
+	db *sql.DB
+}
+
+type sqliteRowScanScenario struct {
+	db *sql.DB
+}
+
+var nanosPerUnit = big.NewInt(1000000000)
+
+func init() {
+	registerBackend(&sqliteBackend{})
+	registerScenario(&backendSumScenario{backend: "sqlite"})
+	registerScenario(&sqliteRowScanScenario{})
+	engineLabels["sqlite"] = "SQLite"
+}
+
+func (b *sqliteBackend) Name() string { return "sqlite" }
+
+func (b *sqliteBackend) Open() error {
+	// WAL + busy_timeout 允许读写并发；写入仍是单写者，Phase 1 的多个消费者会排队提交
+	dsn := "file:" + cfg.SQLitePath + "?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(10000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return err
+	}
+	b.db = db
+	fmt.Printf(">>> SQLite 打开成功: %s\n", cfg.SQLitePath)
+	return nil
+}
+
+func (b *sqliteBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *sqliteBackend) Exists() (bool, error) {
+	var tableExists int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'customer_orders'").Scan(&tableExists)
+	if err != nil {
+		fmt.Printf(">>> [检查数据] SQLite 检查表出错: %v\n", err)
+		return false, nil
+	}
+	if tableExists == 0 {
+		fmt.Println(">>> [检查数据] SQLite 表 customer_orders 不存在")
+		return false, nil
+	}
+	var count int64
+	if err := b.db.QueryRow("SELECT COUNT(*) FROM customer_orders").Scan(&count); err != nil {
+		fmt.Printf(">>> [检查数据] SQLite 统计数据出错: %v\n", err)
+		return false, nil
+	}
+	if count == 0 {
+		fmt.Println(">>> [检查数据] SQLite 表 customer_orders 存在，但数据为空")
+		return false, nil
+	}
+	fmt.Printf(">>> [检查数据] SQLite 表 customer_orders 已存在，当前数据量: %d 条\n", count)
+	return true, nil
+}
+
+func (b *sqliteBackend) InitSchema() error {
+	if cfg.Reload {
+		fmt.Println(">>> SQLite: 由于启用了 reload 参数，将删除并重建表 customer_orders")
+		if _, err := b.db.Exec("DROP TABLE IF EXISTS customer_orders"); err != nil {
+			return fmt.Errorf("drop table: %w", err)
+		}
+	}
+	ddl := []string{
+		`CREATE TABLE IF NOT EXISTS customer_orders (
+			id INTEGER NOT NULL UNIQUE,
+			order_id TEXT PRIMARY KEY,
+			customer_id TEXT,
+			amount TEXT,
+			amount_int INTEGER,
+			amount_nanos INTEGER,
+			create_time TEXT
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_create_time ON customer_orders (create_time)",
+	}
+	for _, stmt := range ddl {
+		if _, err := b.db.Exec(stmt); err != nil {
+			return fmt.Errorf("create table: %w", err)
+		}
+	}
+	if cfg.Reload {
+		fmt.Println(">>> SQLite 表重建完成")
+	} else {
+		fmt.Println(">>> SQLite 表初始化完成（如果表已存在则保留现有数据）")
+	}
+	return nil
+}
+
+// splitAmount 将金额拆分为整数部分与 [0, 1e9) 的纳单位小数部分（负数向下取整，保证 nanos 非负）
+func splitAmount(amount string) (int64, int64, error) {
+	rat, ok := parseDecimal(amount)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	units := new(big.Rat).Mul(rat, amountScaleFactor)
+	if !units.IsInt() {
+		return 0, 0, fmt.Errorf("amount %q has more than %d decimal places", amount, amountScale)
+	}
+	intPart, nanos := new(big.Int).DivMod(units.Num(), nanosPerUnit, new(big.Int))
+	if !intPart.IsInt64() {
+		return 0, 0, fmt.Errorf("amount %q out of range", amount)
+	}
+	return intPart.Int64(), nanos.Int64(), nil
+}
+
+// Load 在单个事务内用预编译语句写入一批订单
+func (b *sqliteBackend) Load(orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO customer_orders (id, order_id, customer_id, amount, amount_int, amount_nanos, create_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, o := range orders {
+		intPart, nanos, err := splitAmount(o.Amount)
+		if err != nil {
+			return fmt.Errorf("order %d: %w", o.ID, err)
+		}
+		if _, err := stmt.Exec(o.ID, o.OrderID, o.CustomerID, o.Amount, intPart, nanos, o.CreateTime); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Retryable 判断写入错误是否可重试：数据库被其他连接锁定（SQLITE_BUSY / SQLITE_LOCKED）
+func (b *sqliteBackend) Retryable(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff { // 低 8 位为主错误码
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return true
+		}
+	}
+	return false
+}
+
+func (b *sqliteBackend) Sum(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: "sqlite-sum", Query: querySumTopN, Engine: "sqlite", Type: "SQLiteSum", Limit: limit}
+	var intSum, nanosSum sql.NullInt64
+	var err error
+
+	if limit == 0 {
+		err = b.db.QueryRow("SELECT SUM(amount_int), SUM(amount_nanos), COUNT(*) FROM customer_orders").
+			Scan(&intSum, &nanosSum, &res.Rows)
+	} else {
+		err = b.db.QueryRow(`
+			SELECT SUM(amount_int), SUM(amount_nanos), COUNT(*) FROM
+			(SELECT amount_int, amount_nanos FROM customer_orders ORDER BY id ASC LIMIT ?) AS subquery
+		`, limit).Scan(&intSum, &nanosSum, &res.Rows)
+	}
+	res.Duration = time.Since(start)
+
+	if err != nil {
+		res.Error = fmt.Sprintf("SQLite Query Error: %v", err)
+		return res
+	}
+
+	// 整数部分 + 小数部分 / 1e9
+	sum := new(big.Rat).SetInt64(intSum.Int64)
+	sum.Add(sum, new(big.Rat).SetFrac(big.NewInt(nanosSum.Int64), nanosPerUnit))
+	res.Sum = sum.FloatString(amountScale)
+	res.Note = "(int + nanos)"
+	return res
+}
+
+// --- SQLite RowScan: 逐行读取 amount 文本，客户端 big.Rat 求和 ---
+// 与 sqlite-sum 互为校验，只启用 SQLite 时也能完整验证一致性校验流程
+
+func (s *sqliteRowScanScenario) Name() string      { return "sqlite-rowscan" }
+func (s *sqliteRowScanScenario) Engines() []string { return []string{"sqlite"} }
+func (s *sqliteRowScanScenario) Description() string {
+	return "SQLite RowScan（SELECT amount ORDER BY id LIMIT n，客户端 big.Rat 求和）"
+}
+
+func (s *sqliteRowScanScenario) Prepare(env *Env) error {
+	b, ok := env.Backend("sqlite").(*sqliteBackend)
+	if !ok {
+		return skipScenario("后端 sqlite 未启用")
+	}
+	s.db = b.db
+	return nil
+}
+
+func (s *sqliteRowScanScenario) Run(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: querySumTopN, Engine: "sqlite", Type: "RowScan", Limit: limit}
+
+	query := "SELECT amount FROM customer_orders ORDER BY id ASC"
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("SQLite RowScan Error: %v", err)
+		return res
+	}
+	defer rows.Close()
+
+	sum := new(big.Rat)
+	amount := new(big.Rat)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("SQLite RowScan Scan Error: %v", err)
+			return res
+		}
+		if _, ok := amount.SetString(raw); !ok {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("SQLite RowScan Error: invalid decimal %q", raw)
+			return res
+		}
+		sum.Add(sum, amount)
+		res.Rows++
+	}
+	res.Duration = time.Since(start)
+	if err := rows.Err(); err != nil {
+		res.Error = fmt.Sprintf("SQLite RowScan Error: %v", err)
+		return res
+	}
+	res.Sum = sum.FloatString(amountScale)
+	res.Note = "(client-side)"
+	return res
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseModes(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"all", []string{"mysql", "es"}},
+		{"mysql", []string{"mysql"}},
+		{" PG , sqlite ", []string{"pg", "sqlite"}},
+		{"es,all,pg", []string{"es", "mysql", "pg"}},
+		{"mysql,mysql,,es", []string{"mysql", "es"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseModes(tt.mode); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseModes(%q) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
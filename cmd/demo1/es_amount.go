@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- ES amount 字段存储策略 ---
+// keyword:      字符串存储，精确但只能通过脚本聚合
+// scaled_float: 缩放因子 1e9 的 scaled_float，可原生聚合（结果为 double）
+// double:       直接存储为 double
+// long_scaled:  乘以 1e9 后存储为 long（整数最小单位）
+// multi:        keyword + scaled_float 子字段 amount.num
+const (
+	amountTypeKeyword     = "keyword"
+	amountTypeScaledFloat = "scaled_float"
+	amountTypeDouble      = "double"
+	amountTypeLongScaled  = "long_scaled"
+	amountTypeMulti       = "multi"
+)
+
+var esAmountTypes = []string{amountTypeKeyword, amountTypeScaledFloat, amountTypeDouble, amountTypeLongScaled, amountTypeMulti}
+
+// amountScale 与 MySQL DECIMAL(19,9) 的小数位保持一致
+const amountScale = 9
+
+var amountScaleFactor = new(big.Rat).SetInt64(1000000000)
+
+func validESAmountType(t string) bool {
+	for _, v := range esAmountTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// esAmountMapping 返回 amount 字段的 mapping 定义
+func esAmountMapping(t string) string {
+	switch t {
+	case amountTypeScaledFloat:
+		return `{ "type": "scaled_float", "scaling_factor": 1000000000 }`
+	case amountTypeDouble:
+		return `{ "type": "double" }`
+	case amountTypeLongScaled:
+		return `{ "type": "long" }`
+	case amountTypeMulti:
+		return `{ "type": "keyword", "fields": { "num": { "type": "scaled_float", "scaling_factor": 1000000000 } } }`
+	default:
+		return `{ "type": "keyword" }`
+	}
+}
+
+// esAmountNumericField 返回可用于原生数值聚合的字段，keyword 策略下返回空
+func esAmountNumericField(t string) string {
+	switch t {
+	case amountTypeKeyword:
+		return ""
+	case amountTypeMulti:
+		return "amount.num"
+	default:
+		return "amount"
+	}
+}
+
+// esAmountScriptExpr 返回 Painless 表达式，将当前文档的 amount 转换为 BigDecimal
+func esAmountScriptExpr(t string) string {
+	switch t {
+	case amountTypeLongScaled:
+		return "java.math.BigDecimal.valueOf(doc['amount'].value, 9)"
+	case amountTypeScaledFloat, amountTypeDouble:
+		return "new java.math.BigDecimal(String.valueOf(doc['amount'].value))"
+	default:
+		// keyword 及 multi 的主字段直接读取字符串值
+		return "new java.math.BigDecimal(doc['amount'].value)"
+	}
+}
+
+// esDocument 按存储策略转换写入 ES 的文档
+// 数值类型默认开启 coerce，字符串金额可以直接写入；只有 long_scaled 需要换算为整数最小单位
+func esDocument(o Order, t string) Order {
+	if t != amountTypeLongScaled {
+		return o
+	}
+	rat, ok := new(big.Rat).SetString(o.Amount)
+	if !ok {
+		return o
+	}
+	o.Amount = new(big.Rat).Mul(rat, amountScaleFactor).FloatString(0)
+	return o
+}
+
+// parseESAmount 解析 _source 或 script_fields 中的 amount 值为 big.Rat
+func parseESAmount(v interface{}, t string) (*big.Rat, bool) {
+	var rat *big.Rat
+	var ok bool
+	switch val := v.(type) {
+	case string:
+		rat, ok = new(big.Rat).SetString(val)
+	case json.Number:
+		rat, ok = new(big.Rat).SetString(val.String())
+	case float64:
+		rat, ok = new(big.Rat).SetString(strconv.FormatFloat(val, 'f', -1, 64))
+	default:
+		return nil, false
+	}
+	if ok && t == amountTypeLongScaled {
+		rat.Quo(rat, amountScaleFactor)
+	}
+	return rat, ok
+}
+
+// esCurrentAmountType 读取已有索引的 amount mapping，推断其存储策略
+func esCurrentAmountType(es *elastic.Client) (string, error) {
+	mappings, err := es.GetMapping().Index("customer_orders").Do(context.Background())
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(mappings["customer_orders"])
+	if err != nil {
+		return "", err
+	}
+	var m struct {
+		Mappings struct {
+			Properties struct {
+				Amount struct {
+					Type   string                     `json:"type"`
+					Fields map[string]json.RawMessage `json:"fields"`
+				} `json:"amount"`
+			} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return "", err
+	}
+	amount := m.Mappings.Properties.Amount
+	switch amount.Type {
+	case "keyword":
+		if _, ok := amount.Fields["num"]; ok {
+			return amountTypeMulti, nil
+		}
+		return amountTypeKeyword, nil
+	case "long":
+		return amountTypeLongScaled, nil
+	case "scaled_float", "double":
+		return amount.Type, nil
+	default:
+		return "", fmt.Errorf("unknown amount mapping type %q", amount.Type)
+	}
+}
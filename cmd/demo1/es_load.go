@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- ES 写入方式（-es-writer） ---
+// bulk:      每个批次同步执行一次 bulk 请求（默认，由 Phase 1 的消费者并发写入）
+// processor: 使用 BulkProcessor 异步写入，由 -es-bulk-workers 个 worker 并发提交，更接近生产环境的写入方式
+// 组批条件为 -es-bulk-actions（文档数）、-es-bulk-size（字节数）、-es-flush-interval（定时刷新），先到先提交
+const (
+	esWriterBulk      = "bulk"
+	esWriterProcessor = "processor"
+)
+
+var esWriters = []string{esWriterBulk, esWriterProcessor}
+
+func validESWriter(w string) bool {
+	for _, v := range esWriters {
+		if v == w {
+			return true
+		}
+	}
+	return false
+}
+
+// esBulkWriter 封装 BulkProcessor，在每次提交完成时把写入结果与请求耗时记入 st
+// BulkProcessor 自带的单项重试只把最后一次提交的响应交给 After 回调，之前被永久拒绝的文档会被漏记，
+// 因此关闭其单项重试，由 after 逐项检查响应：可重试的文档按 -load-retries / -load-backoff 重新入队，其余计为失败
+type esBulkWriter struct {
+	p        *elastic.BulkProcessor
+	st       *loadStats
+	mu       sync.Mutex
+	attempts map[string]int      // _id -> 已重试次数
+	started  map[int64]time.Time // 提交 id -> 开始时间，用于统计请求耗时
+	pending  atomic.Int64        // 等待重新入队的文档数
+}
+
+func newESBulkWriter(client *elastic.Client, st *loadStats) (*esBulkWriter, error) {
+	w := &esBulkWriter{st: st, attempts: map[string]int{}, started: map[int64]time.Time{}}
+	p, err := client.BulkProcessor().
+		Name("demo1-loader").
+		Workers(cfg.ESBulkWorkers).
+		BulkActions(cfg.ESBulkActions).
+		BulkSize(cfg.ESBulkSize).
+		FlushInterval(cfg.ESFlushInterval).
+		Backoff(esRequestBackoff{st: st}).
+		RetryItemStatusCodes().
+		Before(w.before).
+		After(w.after).
+		Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	w.p = p
+	return w, nil
+}
+
+// esRequestBackoff 是整个 bulk 请求失败（如连接错误）时 BulkProcessor 的重试策略，与 -load-retries / -load-backoff 一致
+// BulkProcessor 的多个 worker 共用同一个退避策略，Next 无法得知被重试的请求包含多少文档，
+// 因此整请求重试按请求次数单独记入 st.requestRetries，不计入按行统计的 retried
+type esRequestBackoff struct {
+	st *loadStats
+}
+
+func (b esRequestBackoff) Next(retry int) (time.Duration, bool) {
+	if retry > cfg.LoadRetries {
+		return 0, false
+	}
+	b.st.requestRetries.Add(1)
+	return loadBackoff(retry - 1), true
+}
+
+func (w *esBulkWriter) add(o Order) {
+	w.p.Add(elastic.NewBulkIndexRequest().Index("customer_orders").Id(strconv.FormatInt(o.ID, 10)).Doc(esDocument(o, cfg.ESAmountType)))
+}
+
+// before 在每次提交前调用，记录开始时间
+func (w *esBulkWriter) before(id int64, _ []elastic.BulkableRequest) {
+	w.mu.Lock()
+	w.started[id] = time.Now()
+	w.mu.Unlock()
+}
+
+// after 在每次提交完成后调用（整个请求的重试已包含在内），响应中的 items 与 reqs 一一对应
+func (w *esBulkWriter) after(id int64, reqs []elastic.BulkableRequest, res *elastic.BulkResponse, err error) {
+	w.mu.Lock()
+	start, ok := w.started[id]
+	delete(w.started, id)
+	w.mu.Unlock()
+	if ok {
+		w.st.recordBatch(time.Since(start))
+	}
+	if res == nil || len(res.Items) != len(reqs) {
+		if err == nil {
+			err = fmt.Errorf("unexpected bulk response")
+		}
+		w.st.failed.Add(int64(len(reqs)))
+		log.Printf("ES Write Error: %d row(s) failed after %d retries: %v", len(reqs), cfg.LoadRetries, err)
+		return
+	}
+	var written, rejected int
+	var cause string
+	for i, item := range res.Items {
+		for _, r := range item {
+			if r.Error == nil && r.Status < 300 {
+				written++
+				w.forget(r.Id)
+				continue
+			}
+			if esRetryableStatus(r.Status) && w.retry(r.Id, reqs[i]) {
+				continue
+			}
+			rejected++
+			if cause == "" {
+				cause = fmt.Sprintf("status %d: %s", r.Status, esItemError(r.Error))
+			}
+		}
+	}
+	w.st.written.Add(int64(written))
+	if rejected > 0 {
+		w.st.failed.Add(int64(rejected))
+		log.Printf("ES Write Error: %d row(s) rejected: %s", rejected, cause)
+	}
+}
+
+// retry 在重试次数未用完时等待退避时间后把文档重新加入 BulkProcessor，返回是否已安排重试
+func (w *esBulkWriter) retry(id string, req elastic.BulkableRequest) bool {
+	w.mu.Lock()
+	attempt := w.attempts[id]
+	if attempt >= cfg.LoadRetries {
+		delete(w.attempts, id)
+		w.mu.Unlock()
+		return false
+	}
+	w.attempts[id] = attempt + 1
+	w.mu.Unlock()
+
+	w.st.retried.Add(1)
+	w.pending.Add(1)
+	go func() {
+		// after 在 worker 中同步执行，不能直接 Add（所有 worker 都在等待时会死锁）
+		time.Sleep(loadBackoff(attempt))
+		w.p.Add(req)
+		w.pending.Add(-1)
+	}()
+	return true
+}
+
+// forget 在文档写入成功后清除其重试次数
+func (w *esBulkWriter) forget(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.attempts) > 0 {
+		delete(w.attempts, id)
+	}
+}
+
+// close 反复刷新直到没有等待重试的文档，然后关闭 BulkProcessor
+func (w *esBulkWriter) close() error {
+	for {
+		if err := w.p.Flush(); err != nil {
+			return err
+		}
+		if w.pending.Load() == 0 {
+			break
+		}
+		for w.pending.Load() > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return w.p.Close()
+}
+
+// --- 写入期间的索引调优（-es-load-tuning） ---
+// 加载期间关闭 refresh（refresh_interval=-1）并去掉副本（number_of_replicas=0），加载完成后恢复原值
+
+type esIndexSettings struct {
+	refreshInterval  *string // nil 表示原来未设置（使用集群默认值）
+	numberOfReplicas string
+}
+
+// esApplyLoadTuning 记录当前设置并切换为写入优化的设置
+func esApplyLoadTuning(client *elastic.Client) (*esIndexSettings, error) {
+	ctx := context.Background()
+	resp, err := client.IndexGetSettings("customer_orders").Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get settings: %w", err)
+	}
+	saved := &esIndexSettings{numberOfReplicas: "1"}
+	if info, ok := resp["customer_orders"]; ok {
+		if index, ok := info.Settings["index"].(map[string]interface{}); ok {
+			if v, ok := index["refresh_interval"].(string); ok {
+				saved.refreshInterval = &v
+			}
+			if v, ok := index["number_of_replicas"].(string); ok {
+				saved.numberOfReplicas = v
+			}
+		}
+	}
+	if _, err := client.IndexPutSettings("customer_orders").
+		BodyString(`{"index":{"refresh_interval":"-1","number_of_replicas":0}}`).Do(ctx); err != nil {
+		return nil, fmt.Errorf("put settings: %w", err)
+	}
+	fmt.Printf(">>> Elasticsearch 写入调优: refresh_interval=-1, number_of_replicas=0（原值: refresh_interval=%s, number_of_replicas=%s）\n",
+		saved.describeRefresh(), saved.numberOfReplicas)
+	return saved, nil
+}
+
+// restore 恢复加载前的设置，refresh_interval 原来未设置时恢复为集群默认值
+func (s *esIndexSettings) restore(client *elastic.Client) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"index": map[string]interface{}{
+			"refresh_interval":   s.refreshInterval,
+			"number_of_replicas": s.numberOfReplicas,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := client.IndexPutSettings("customer_orders").BodyString(string(body)).Do(context.Background()); err != nil {
+		return fmt.Errorf("restore settings: %w", err)
+	}
+	fmt.Printf(">>> Elasticsearch 写入调优已恢复: refresh_interval=%s, number_of_replicas=%s\n", s.describeRefresh(), s.numberOfReplicas)
+	return nil
+}
+
+func (s *esIndexSettings) describeRefresh() string {
+	if s.refreshInterval == nil {
+		return "默认"
+	}
+	return *s.refreshInterval
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- 数据集导出（-export-dir） ---
+// Phase 1 的生产者按 id 顺序把每条订单同时写入导出文件，便于把完全相同的数据加载到其他集群，
+// 或脱离本工具重放同一份数据集（CSV/NDJSON 也可以直接作为 -input 使用）
+const (
+	exportCSV    = "csv"    // orders.csv
+	exportNDJSON = "ndjson" // orders.ndjson
+	exportMySQL  = "mysql"  // orders.tsv + load_mysql.sql（LOAD DATA 默认格式）
+	exportES     = "es"     // orders.es.bulk.ndjson + es_mapping.json（_bulk 请求体）
+)
+
+var exportFormats = []string{exportCSV, exportNDJSON, exportMySQL, exportES}
+
+func validExportFormat(f string) bool {
+	for _, v := range exportFormats {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// exportWriter 是一种导出格式的数据文件
+type exportWriter struct {
+	path  string
+	f     *os.File
+	w     *bufio.Writer
+	write func(o Order) error
+	flush func() error // 额外的缓冲（如 csv.Writer）需要在关闭前刷新
+}
+
+type exporter struct {
+	dir     string
+	writers []*exportWriter
+	rows    int64
+}
+
+// newExporter 在 dir 下为每种格式创建数据文件，同时写入建表语句与索引 mapping 等辅助文件
+func newExporter(dir string, formats []string) (*exporter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	e := &exporter{dir: dir}
+	for _, format := range formats {
+		var err error
+		switch format {
+		case exportCSV:
+			err = e.addCSV()
+		case exportNDJSON:
+			err = e.addNDJSON()
+		case exportMySQL:
+			err = e.addMySQL()
+		case exportES:
+			err = e.addES()
+		}
+		if err != nil {
+			e.close()
+			return nil, fmt.Errorf("export %s: %w", format, err)
+		}
+	}
+	return e, nil
+}
+
+func (e *exporter) create(name string) (*exportWriter, error) {
+	path := filepath.Join(e.dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &exportWriter{path: path, f: f, w: bufio.NewWriterSize(f, 1<<20)}
+	e.writers = append(e.writers, w)
+	return w, nil
+}
+
+func (e *exporter) addCSV() error {
+	w, err := e.create("orders.csv")
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w.w)
+	w.flush = func() error {
+		cw.Flush()
+		return cw.Error()
+	}
+	record := make([]string, 5)
+	w.write = func(o Order) error {
+		record[0] = strconv.FormatInt(o.ID, 10)
+		record[1], record[2], record[3], record[4] = o.OrderID, o.CustomerID, o.Amount, o.CreateTime
+		return cw.Write(record)
+	}
+	return cw.Write([]string{"id", "order_id", "customer_id", "amount", "create_time"})
+}
+
+func (e *exporter) addNDJSON() error {
+	w, err := e.create("orders.ndjson")
+	if err != nil {
+		return err
+	}
+	w.write = func(o Order) error {
+		return e.writeJSONLine(w.w, o)
+	}
+	return nil
+}
+
+// addMySQL 导出 LOAD DATA 默认格式（制表符分隔、反斜杠转义）的数据文件与对应的加载脚本
+func (e *exporter) addMySQL() error {
+	w, err := e.create("orders.tsv")
+	if err != nil {
+		return err
+	}
+	var row []byte
+	w.write = func(o Order) error {
+		row = appendLoadDataRow(row[:0], o)
+		_, err := w.w.Write(row)
+		return err
+	}
+	script := mysqlCreateTable + ";\n\n" +
+		"-- mysql --local-infile=1 <db> < load_mysql.sql（在导出目录下执行）\n" +
+		"LOAD DATA LOCAL INFILE 'orders.tsv' INTO TABLE customer_orders\n" +
+		"\tCHARACTER SET utf8mb4\n" +
+		"\t(id, order_id, customer_id, amount, create_time);\n"
+	return os.WriteFile(filepath.Join(e.dir, "load_mysql.sql"), []byte(script), 0o644)
+}
+
+// LOAD DATA 默认 FIELDS ESCAPED BY '\\'，需要转义反斜杠、制表符与换行
+var mysqlLoadDataEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
+// addES 导出 _bulk 请求体（action 行 + 文档行）与建索引用的 mapping，文档与 -es-amount-type 的写入格式一致
+func (e *exporter) addES() error {
+	w, err := e.create("orders.es.bulk.ndjson")
+	if err != nil {
+		return err
+	}
+	amountType := cfg.ESAmountType
+	w.write = func(o Order) error {
+		// 使用订单 id 作为 _id，重复导入同一文件不会产生重复文档
+		if _, err := fmt.Fprintf(w.w, `{"index":{"_index":"customer_orders","_id":"%d"}}`+"\n", o.ID); err != nil {
+			return err
+		}
+		return e.writeJSONLine(w.w, esDocument(o, amountType))
+	}
+	return os.WriteFile(filepath.Join(e.dir, "es_mapping.json"), []byte(esIndexMapping(amountType)+"\n"), 0o644)
+}
+
+func (e *exporter) writeJSONLine(w *bufio.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// exportingSource 在读取订单的同时写入导出文件，生产者按顺序读取，因此导出文件与加载顺序一致
+type exportingSource struct {
+	orderSource
+	exp *exporter
+}
+
+func (s *exportingSource) next() (Order, int64, error) {
+	order, units, err := s.orderSource.next()
+	if err != nil {
+		return order, units, err
+	}
+	if err := s.exp.write(order); err != nil {
+		return Order{}, 0, fmt.Errorf("export: %w", err)
+	}
+	return order, units, nil
+}
+
+func (s *exportingSource) close() error {
+	err := s.exp.close()
+	if cerr := s.orderSource.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// write 把一条订单写入所有导出文件，必须按生成顺序在单个 goroutine 中调用
+func (e *exporter) write(o Order) error {
+	for _, w := range e.writers {
+		if err := w.write(o); err != nil {
+			return fmt.Errorf("write %s: %w", w.path, err)
+		}
+	}
+	e.rows++
+	return nil
+}
+
+// close 刷新并关闭所有文件，返回第一个错误
+func (e *exporter) close() error {
+	var first error
+	for _, w := range e.writers {
+		var err error
+		if w.flush != nil {
+			err = w.flush()
+		}
+		if ferr := w.w.Flush(); err == nil {
+			err = ferr
+		}
+		if cerr := w.f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil && first == nil {
+			first = fmt.Errorf("close %s: %w", w.path, err)
+		}
+	}
+	return first
+}
+
+// files 返回导出的数据文件路径
+func (e *exporter) files() []string {
+	paths := make([]string, len(e.writers))
+	for i, w := range e.writers {
+		paths[i] = w.path
+	}
+	return paths
+}
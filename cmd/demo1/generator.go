@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- 订单生成器 ---
+// 所有随机值都来自以 -seed 初始化的独立随机源，OrderID 与 CreateTime 由种子和行号决定，
+// 相同的 -seed、-total 与 data.distribution 会逐字节生成相同的数据集
+type orderGenerator struct {
+	seed     int64
+	rng      *rand.Rand
+	dist     distributionConfig
+	zipf     *rand.Zipf     // customers.type=zipf 时使用
+	buckets  weightedChoice // amount.type=buckets 时按权重选择区间
+	hours    weightedChoice // time.diurnal=true 时按权重选择小时
+	maxUnits int64          // 金额上限 * 1e9
+}
+
+// 生成数据的起始时间（固定值，不依赖运行时刻）
+var generatorBaseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newOrderGenerator(seed int64, dist distributionConfig) *orderGenerator {
+	g := &orderGenerator{seed: seed, rng: rand.New(rand.NewSource(seed)), dist: dist}
+	g.maxUnits = int64(dist.Amount.Max * 1e9)
+	if dist.Customers.Type == distZipf {
+		g.zipf = rand.NewZipf(g.rng, dist.Customers.S, dist.Customers.V, uint64(dist.Customers.Count-1))
+	}
+	if dist.Amount.Type == distBuckets {
+		weights := make([]float64, len(dist.Amount.Buckets))
+		for i, b := range dist.Amount.Buckets {
+			weights[i] = b.Weight
+		}
+		g.buckets = newWeightedChoice(weights)
+	}
+	if dist.Time.Type == distSpread && dist.Time.Diurnal {
+		g.hours = newWeightedChoice(dist.Time.HourWeights)
+	}
+	return g
+}
+
+// next 生成第 i 条（从 0 开始）订单，同时返回金额的 1e-9 单位整数值
+// 必须按 i 递增的顺序调用，随机源的状态依赖调用顺序
+func (g *orderGenerator) next(i int) (Order, int64) {
+	// 使用big.Rat生成精确的9位小数金额字符串，避免float64精度损失
+	units := g.amountUnits()
+	amount := new(big.Rat).SetFrac64(units, 1000000000)
+	createTime := g.createTime(i)
+	order := Order{
+		ID:         int64(i) + 1,
+		OrderID:    fmt.Sprintf("ORD-%d-%d", g.seed, i),
+		CustomerID: fmt.Sprintf("CUST-%d", g.customer()),
+		Amount:     amount.FloatString(9), // 直接生成精确的9位小数字符串
+		CreateTime: createTime.Format(createTimeLayout),
+	}
+	return order, units
+}
+
+// amountUnits 按 amount 分布生成金额（单位 1e-9），结果在 [0, max) 内
+func (g *orderGenerator) amountUnits() int64 {
+	a := g.dist.Amount
+	switch a.Type {
+	case distLogNormal:
+		// 超出上限的样本重新抽样，保持分布形状；极端参数下多次失败才截断到上限以内
+		for attempt := 0; attempt < 100; attempt++ {
+			if v := math.Exp(*a.Mu + *a.Sigma*g.rng.NormFloat64()); v < a.Max {
+				return int64(v * 1e9)
+			}
+		}
+		return g.maxUnits - 1
+	case distBuckets:
+		b := a.Buckets[g.buckets.pick(g.rng)]
+		lo, hi := int64(b.Min*1e9), int64(b.Max*1e9)
+		return lo + g.rng.Int63n(hi-lo)
+	default:
+		return g.rng.Int63n(g.maxUnits)
+	}
+}
+
+// createTime 按 time 分布生成第 i 条订单的创建时间
+func (g *orderGenerator) createTime(i int) time.Time {
+	t := g.dist.Time
+	if t.Type != distSpread {
+		// 每行间隔 1ms，再叠加不超过 1ms 的随机微秒偏移，create_time 随 id 单调递增
+		return generatorBaseTime.Add(time.Duration(i)*time.Millisecond + time.Duration(g.rng.Int63n(1000))*time.Microsecond)
+	}
+	// 在 [end - window_days, end) 内随机选一天，再按小时权重（或均匀）选小时，小时内均匀分布到微秒
+	day := g.rng.Intn(t.WindowDays)
+	hour := g.rng.Intn(24)
+	if t.Diurnal {
+		hour = g.hours.pick(g.rng)
+	}
+	offset := time.Duration(g.rng.Int63n(int64(time.Hour/time.Microsecond))) * time.Microsecond
+	start := t.end.AddDate(0, 0, -t.WindowDays)
+	return start.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + offset)
+}
+
+// customer 按 customers 分布返回客户编号，zipf 分布下编号越小的客户订单越多
+func (g *orderGenerator) customer() int {
+	if g.zipf != nil {
+		return int(g.zipf.Uint64())
+	}
+	return g.rng.Intn(g.dist.Customers.Count)
+}
+
+// weightedChoice 保存累计权重，pick 按权重返回下标
+type weightedChoice []float64
+
+func newWeightedChoice(weights []float64) weightedChoice {
+	cum := make(weightedChoice, len(weights))
+	var total float64
+	for i, w := range weights {
+		total += w
+		cum[i] = total
+	}
+	return cum
+}
+
+func (w weightedChoice) pick(rng *rand.Rand) int {
+	x := rng.Float64() * w[len(w)-1]
+	return sort.Search(len(w), func(i int) bool { return w[i] > x })
+}
+
+// --- 数据分布配置（config.yaml 的 data.distribution） ---
+// 默认值与未配置时的生成结果一致：客户均匀分布在 100000 个，金额均匀分布在 [0, 100000)，create_time 按 id 每行递增 1ms
+const (
+	distUniform    = "uniform"
+	distZipf       = "zipf"
+	distLogNormal  = "lognormal"
+	distBuckets    = "buckets"
+	distSequential = "sequential"
+	distSpread     = "spread"
+)
+
+// maxAmountLimit 为生成金额的上限，与默认 uniform 分布一致；金额越大 ES scaled_float/double 的精度损失越明显
+const maxAmountLimit = 100000
+
+type distributionConfig struct {
+	Customers customerDistribution `yaml:"customers"`
+	Amount    amountDistribution   `yaml:"amount"`
+	Time      timeDistribution     `yaml:"time"`
+}
+
+type customerDistribution struct {
+	Type  string  `yaml:"type"`  // uniform(默认), zipf
+	Count int     `yaml:"count"` // 客户数，默认 100000
+	S     float64 `yaml:"s"`     // zipf 指数，必须 > 1，默认 1.1（越大越倾斜）
+	V     float64 `yaml:"v"`     // zipf 参数，必须 >= 1，默认 1
+}
+
+type amountDistribution struct {
+	Type    string         `yaml:"type"`    // uniform(默认), lognormal, buckets
+	Max     float64        `yaml:"max"`     // 金额上限（不含），默认且最大 100000
+	Mu      *float64       `yaml:"mu"`      // lognormal: ln(金额) 的均值，默认 5（中位数约 148）；未配置时为 nil，显式的 0 保留
+	Sigma   *float64       `yaml:"sigma"`   // lognormal: ln(金额) 的标准差，默认 1.5；0 表示金额恒为 e^mu
+	Buckets []amountBucket `yaml:"buckets"` // buckets: 按权重选择区间，区间内均匀分布
+}
+
+type amountBucket struct {
+	Min    float64 `yaml:"min"`
+	Max    float64 `yaml:"max"`
+	Weight float64 `yaml:"weight"`
+}
+
+type timeDistribution struct {
+	Type        string    `yaml:"type"`         // sequential(默认), spread
+	WindowDays  int       `yaml:"window_days"`  // spread: 历史窗口天数，默认 365
+	End         string    `yaml:"end"`          // spread: 窗口结束日期 (YYYY-MM-DD)，默认 2024-01-01
+	Diurnal     bool      `yaml:"diurnal"`      // spread: 是否按小时权重模拟昼夜波动
+	HourWeights []float64 `yaml:"hour_weights"` // spread: 0~23 点的权重，默认白天与晚间高峰、凌晨低谷
+	end         time.Time
+}
+
+// defaultHourWeights 模拟电商订单的昼夜波动：凌晨低谷，上午与 20~21 点为高峰
+var defaultHourWeights = []float64{3, 2, 1, 1, 1, 1, 2, 4, 6, 8, 9, 10, 10, 9, 8, 8, 8, 9, 10, 11, 12, 11, 8, 5}
+
+// normalize 填充默认值并校验参数
+func (d *distributionConfig) normalize() error {
+	c := &d.Customers
+	if c.Type == "" {
+		c.Type = distUniform
+	}
+	if c.Count == 0 {
+		c.Count = 100000
+	}
+	if c.S == 0 {
+		c.S = 1.1
+	}
+	if c.V == 0 {
+		c.V = 1
+	}
+	if c.Type != distUniform && c.Type != distZipf {
+		return fmt.Errorf("customers.type %q (supported: uniform, zipf)", c.Type)
+	}
+	if c.Count < 1 {
+		return fmt.Errorf("customers.count %d must be >= 1", c.Count)
+	}
+	if c.Type == distZipf && (c.S <= 1 || c.V < 1) {
+		return fmt.Errorf("customers zipf requires s > 1 and v >= 1 (got s=%v, v=%v)", c.S, c.V)
+	}
+
+	a := &d.Amount
+	if a.Type == "" {
+		a.Type = distUniform
+	}
+	if a.Max == 0 {
+		a.Max = maxAmountLimit
+	}
+	if a.Mu == nil {
+		mu := 5.0
+		a.Mu = &mu
+	}
+	if a.Sigma == nil {
+		sigma := 1.5
+		a.Sigma = &sigma
+	}
+	if a.Max < 0 || a.Max > maxAmountLimit {
+		return fmt.Errorf("amount.max %v must be in (0, %d]", a.Max, maxAmountLimit)
+	}
+	if int64(a.Max*1e9) < 1 {
+		return fmt.Errorf("amount.max %v is too small", a.Max)
+	}
+	switch a.Type {
+	case distUniform:
+	case distLogNormal:
+		if *a.Sigma < 0 {
+			return fmt.Errorf("amount.sigma %v must be >= 0", *a.Sigma)
+		}
+	case distBuckets:
+		if len(a.Buckets) == 0 {
+			return fmt.Errorf("amount.buckets is empty")
+		}
+		for i, b := range a.Buckets {
+			if b.Min < 0 || int64(b.Min*1e9) >= int64(b.Max*1e9) || b.Max > a.Max {
+				return fmt.Errorf("amount.buckets[%d] range [%v, %v) must satisfy 0 <= min < max <= %v", i, b.Min, b.Max, a.Max)
+			}
+			if b.Weight <= 0 {
+				return fmt.Errorf("amount.buckets[%d] weight %v must be > 0", i, b.Weight)
+			}
+		}
+	default:
+		return fmt.Errorf("amount.type %q (supported: uniform, lognormal, buckets)", a.Type)
+	}
+
+	t := &d.Time
+	if t.Type == "" {
+		t.Type = distSequential
+	}
+	if t.WindowDays == 0 {
+		t.WindowDays = 365
+	}
+	if len(t.HourWeights) == 0 {
+		t.HourWeights = defaultHourWeights
+	}
+	switch t.Type {
+	case distSequential:
+	case distSpread:
+		if t.WindowDays < 1 {
+			return fmt.Errorf("time.window_days %d must be >= 1", t.WindowDays)
+		}
+		t.end = generatorBaseTime
+		if t.End != "" {
+			end, err := time.Parse("2006-01-02", t.End)
+			if err != nil {
+				return fmt.Errorf("time.end %q must be YYYY-MM-DD", t.End)
+			}
+			t.end = end
+		}
+		if len(t.HourWeights) != 24 {
+			return fmt.Errorf("time.hour_weights must have 24 values (got %d)", len(t.HourWeights))
+		}
+		var total float64
+		for _, w := range t.HourWeights {
+			if w < 0 {
+				return fmt.Errorf("time.hour_weights must be >= 0")
+			}
+			total += w
+		}
+		if total == 0 {
+			return fmt.Errorf("time.hour_weights must not be all zero")
+		}
+	default:
+		return fmt.Errorf("time.type %q (supported: sequential, spread)", t.Type)
+	}
+	return nil
+}
+
+// String 返回分布的简要描述，用于启动日志与报告
+func (d distributionConfig) String() string {
+	c, a, t := d.Customers, d.Amount, d.Time
+	customers := fmt.Sprintf("uniform(n=%d)", c.Count)
+	if c.Type == distZipf {
+		customers = fmt.Sprintf("zipf(n=%d,s=%v,v=%v)", c.Count, c.S, c.V)
+	}
+	amount := fmt.Sprintf("uniform(max=%v)", a.Max)
+	switch a.Type {
+	case distLogNormal:
+		amount = fmt.Sprintf("lognormal(mu=%v,sigma=%v,max=%v)", *a.Mu, *a.Sigma, a.Max)
+	case distBuckets:
+		parts := make([]string, len(a.Buckets))
+		for i, b := range a.Buckets {
+			parts[i] = fmt.Sprintf("[%v,%v):%v", b.Min, b.Max, b.Weight)
+		}
+		amount = fmt.Sprintf("buckets(%s)", strings.Join(parts, " "))
+	}
+	createTime := "sequential(1ms)"
+	if t.Type == distSpread {
+		createTime = fmt.Sprintf("spread(%dd,end=%s", t.WindowDays, t.end.Format("2006-01-02"))
+		if t.Diurnal {
+			createTime += ",diurnal"
+		}
+		createTime += ")"
+	}
+	return fmt.Sprintf("customers=%s amount=%s time=%s", customers, amount, createTime)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// testDistributions 覆盖各类分布，用于确定性测试
+var testDistributions = map[string]string{
+	"default": ``,
+	"skewed": `
+customers: {type: zipf, count: 1000, s: 1.5}
+amount: {type: lognormal, mu: 3, sigma: 2}
+time: {type: spread, window_days: 30, diurnal: true}
+`,
+	"buckets": `
+amount:
+  type: buckets
+  buckets:
+    - {min: 0, max: 10, weight: 9}
+    - {min: 1000, max: 5000, weight: 1}
+`,
+}
+
+func parseDistribution(t *testing.T, src string) distributionConfig {
+	t.Helper()
+	var d distributionConfig
+	if err := yaml.Unmarshal([]byte(src), &d); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := d.normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	return d
+}
+
+func generateOrders(seed int64, dist distributionConfig, n int) ([]Order, []int64) {
+	src := &generatorSource{gen: newOrderGenerator(seed, dist), total: n}
+	var orders []Order
+	var units []int64
+	for {
+		o, u, err := src.next()
+		if err != nil {
+			break
+		}
+		orders = append(orders, o)
+		units = append(units, u)
+	}
+	return orders, units
+}
+
+func TestGeneratorSameSeedSameRows(t *testing.T) {
+	for name, src := range testDistributions {
+		t.Run(name, func(t *testing.T) {
+			dist := parseDistribution(t, src)
+			a, au := generateOrders(42, dist, 2000)
+			b, bu := generateOrders(42, dist, 2000)
+			if len(a) != 2000 || len(b) != 2000 {
+				t.Fatalf("generated %d and %d rows, want 2000", len(a), len(b))
+			}
+			for i := range a {
+				if a[i] != b[i] || au[i] != bu[i] {
+					t.Fatalf("row %d differs: %+v (%d) vs %+v (%d)", i, a[i], au[i], b[i], bu[i])
+				}
+				if a[i].ID != int64(i+1) {
+					t.Fatalf("row %d has id %d", i, a[i].ID)
+				}
+				if au[i] < 0 || au[i] >= int64(dist.Amount.Max*1e9) {
+					t.Fatalf("row %d amount %d out of [0, %v)", i, au[i], dist.Amount.Max)
+				}
+			}
+
+			c, _ := generateOrders(43, dist, 2000)
+			same := 0
+			for i := range a {
+				if a[i].Amount == c[i].Amount {
+					same++
+				}
+			}
+			if same == len(a) {
+				t.Error("seeds 42 and 43 generated identical amounts")
+			}
+		})
+	}
+}
+
+func TestDistributionDefaults(t *testing.T) {
+	d := parseDistribution(t, "")
+	if d.Customers.Type != distUniform || d.Customers.Count != 100000 {
+		t.Errorf("customers = %+v, want uniform with 100000 customers", d.Customers)
+	}
+	if d.Amount.Type != distUniform || d.Amount.Max != maxAmountLimit {
+		t.Errorf("amount = %+v, want uniform with max %d", d.Amount, maxAmountLimit)
+	}
+	if *d.Amount.Mu != 5 || *d.Amount.Sigma != 1.5 {
+		t.Errorf("mu, sigma = %v, %v; want defaults 5, 1.5", *d.Amount.Mu, *d.Amount.Sigma)
+	}
+	if d.Time.Type != distSequential {
+		t.Errorf("time.type = %q, want %q", d.Time.Type, distSequential)
+	}
+}
+
+func TestDistributionExplicitZeroMuSigma(t *testing.T) {
+	d := parseDistribution(t, "amount: {type: lognormal, mu: 0, sigma: 0}")
+	if *d.Amount.Mu != 0 || *d.Amount.Sigma != 0 {
+		t.Fatalf("mu, sigma = %v, %v; want explicit 0, 0 kept", *d.Amount.Mu, *d.Amount.Sigma)
+	}
+	// sigma=0 时金额恒为 e^mu = 1
+	orders, _ := generateOrders(1, d, 100)
+	for _, o := range orders {
+		if o.Amount != "1.000000000" {
+			t.Fatalf("amount = %s, want constant 1.000000000", o.Amount)
+		}
+	}
+}
+
+func TestDistributionInvalid(t *testing.T) {
+	tests := map[string]string{
+		"negative sigma":  "amount: {type: lognormal, sigma: -1}",
+		"unknown amount":  "amount: {type: normal}",
+		"max too large":   "amount: {max: 200000}",
+		"zipf s":          "customers: {type: zipf, s: 1}",
+		"empty buckets":   "amount: {type: buckets}",
+		"bucket range":    "amount: {type: buckets, buckets: [{min: 5, max: 5, weight: 1}]}",
+		"bucket weight":   "amount: {type: buckets, buckets: [{min: 0, max: 5, weight: 0}]}",
+		"hour weights":    "time: {type: spread, hour_weights: [1, 2]}",
+		"time end format": "time: {type: spread, end: 2024/01/01}",
+	}
+	for name, src := range tests {
+		var d distributionConfig
+		if err := yaml.Unmarshal([]byte(src), &d); err != nil {
+			t.Fatalf("%s: unmarshal: %v", name, err)
+		}
+		if err := d.normalize(); err == nil {
+			t.Errorf("%s: normalize(%s) = nil, want error", name, strings.TrimSpace(src))
+		}
+	}
+}
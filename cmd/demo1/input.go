@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Phase 1 数据来源 ---
+// 默认使用订单生成器；指定 -input 时从 CSV/NDJSON 文件流式读取（如脱敏后的生产导出），
+// 两者都逐条送入 loadData 的批次通道，Phase 2 的查询不受数据来源影响
+type orderSource interface {
+	// next 返回下一条订单及其金额的 1e-9 单位整数值，读完时返回 io.EOF
+	next() (Order, int64, error)
+	close() error
+}
+
+// generatorSource 按 -total 依次生成订单
+type generatorSource struct {
+	gen   *orderGenerator
+	i     int
+	total int
+}
+
+func (s *generatorSource) next() (Order, int64, error) {
+	if s.i >= s.total {
+		return Order{}, 0, io.EOF
+	}
+	order, units := s.gen.next(s.i)
+	s.i++
+	return order, units, nil
+}
+
+func (s *generatorSource) close() error { return nil }
+
+const (
+	inputFormatCSV    = "csv"
+	inputFormatNDJSON = "ndjson"
+)
+
+// --- 外部文件配置（config.yaml 的 input，-input 覆盖 path） ---
+type inputConfig struct {
+	Path       string       `yaml:"path"`        // 输入文件路径，为空时使用订单生成器
+	Format     string       `yaml:"format"`      // csv, ndjson；为空时按扩展名判断（.csv / .ndjson / .jsonl）
+	Delimiter  string       `yaml:"delimiter"`   // CSV 分隔符，默认 ","
+	TimeLayout string       `yaml:"time_layout"` // create_time 的 Go 时间格式，默认 "2006-01-02 15:04:05.000000"
+	Columns    inputColumns `yaml:"columns"`     // Order 字段到 CSV 表头 / NDJSON 键的映射
+	delimiter  rune
+}
+
+type inputColumns struct {
+	ID         string `yaml:"id"` // 为空时按文件顺序从 1 生成
+	OrderID    string `yaml:"order_id"`
+	CustomerID string `yaml:"customer_id"`
+	Amount     string `yaml:"amount"`
+	CreateTime string `yaml:"create_time"`
+}
+
+// normalize 填充默认值并校验参数，未指定输入文件时不做任何检查
+func (c *inputConfig) normalize() error {
+	if c.Path == "" {
+		return nil
+	}
+	if c.Format == "" {
+		switch strings.ToLower(filepath.Ext(c.Path)) {
+		case ".csv":
+			c.Format = inputFormatCSV
+		case ".ndjson", ".jsonl":
+			c.Format = inputFormatNDJSON
+		default:
+			return fmt.Errorf("cannot infer format from %q, set input.format to csv or ndjson", c.Path)
+		}
+	}
+	if c.Format != inputFormatCSV && c.Format != inputFormatNDJSON {
+		return fmt.Errorf("format %q (supported: csv, ndjson)", c.Format)
+	}
+	if c.Delimiter == "" {
+		c.Delimiter = ","
+	}
+	if c.Delimiter == `\t` {
+		c.Delimiter = "\t"
+	}
+	r, size := utf8.DecodeRuneInString(c.Delimiter)
+	if size != len(c.Delimiter) || r == '"' || r == '\r' || r == '\n' {
+		return fmt.Errorf("delimiter %q must be a single character", c.Delimiter)
+	}
+	c.delimiter = r
+	if c.TimeLayout == "" {
+		c.TimeLayout = createTimeLayout
+	}
+	cols := &c.Columns
+	if cols.OrderID == "" {
+		cols.OrderID = "order_id"
+	}
+	if cols.CustomerID == "" {
+		cols.CustomerID = "customer_id"
+	}
+	if cols.Amount == "" {
+		cols.Amount = "amount"
+	}
+	if cols.CreateTime == "" {
+		cols.CreateTime = "create_time"
+	}
+	return nil
+}
+
+// String 返回输入文件的简要描述，用于启动日志与报告
+func (c inputConfig) String() string {
+	if c.Path == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", c.Path, c.Format)
+}
+
+// openInputSource 打开 -input 指定的文件
+func openInputSource(c inputConfig) (orderSource, error) {
+	f, err := os.Open(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReaderSize(f, 1<<20)
+	if c.Format == inputFormatNDJSON {
+		dec := json.NewDecoder(r)
+		dec.UseNumber() // 保留数字金额的原始文本，避免 float64 精度损失
+		return &ndjsonSource{f: f, dec: dec, mapper: inputMapper{cfg: c}}, nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma = c.delimiter
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, col := range c.Columns.required() {
+		if _, ok := index[col]; !ok {
+			f.Close()
+			return nil, fmt.Errorf("csv header has no column %q (header: %s)", col, strings.Join(header, ","))
+		}
+	}
+	return &csvSource{f: f, r: cr, index: index, mapper: inputMapper{cfg: c}}, nil
+}
+
+// required 返回必须存在的列
+func (c inputColumns) required() []string {
+	cols := []string{c.OrderID, c.CustomerID, c.Amount, c.CreateTime}
+	if c.ID != "" {
+		cols = append(cols, c.ID)
+	}
+	return cols
+}
+
+type csvSource struct {
+	f      *os.File
+	r      *csv.Reader
+	index  map[string]int
+	mapper inputMapper
+}
+
+func (s *csvSource) next() (Order, int64, error) {
+	record, err := s.r.Read()
+	if err == io.EOF {
+		return Order{}, 0, io.EOF
+	}
+	if err != nil {
+		return Order{}, 0, err
+	}
+	line, _ := s.r.FieldPos(0)
+	order, units, err := s.mapper.order(func(col string) (string, bool) {
+		i := s.index[col]
+		if i >= len(record) {
+			return "", false
+		}
+		return record[i], true
+	})
+	if err != nil {
+		return Order{}, 0, fmt.Errorf("line %d: %w", line, err)
+	}
+	return order, units, nil
+}
+
+func (s *csvSource) close() error { return s.f.Close() }
+
+type ndjsonSource struct {
+	f      *os.File
+	dec    *json.Decoder
+	line   int
+	mapper inputMapper
+}
+
+func (s *ndjsonSource) next() (Order, int64, error) {
+	var doc map[string]interface{}
+	if err := s.dec.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return Order{}, 0, io.EOF
+		}
+		return Order{}, 0, fmt.Errorf("record %d: %w", s.line+1, err)
+	}
+	s.line++
+	order, units, err := s.mapper.order(func(key string) (string, bool) {
+		switch v := doc[key].(type) {
+		case string:
+			return v, true
+		case json.Number:
+			return v.String(), true
+		case nil:
+			return "", false
+		default:
+			return fmt.Sprint(v), true
+		}
+	})
+	if err != nil {
+		return Order{}, 0, fmt.Errorf("record %d: %w", s.line, err)
+	}
+	return order, units, nil
+}
+
+func (s *ndjsonSource) close() error { return s.f.Close() }
+
+// inputMapper 按列映射把一条记录转换为 Order
+// amount 统一规范化为 9 位小数，create_time 统一转换为 UTC 的 createTimeLayout，保证各后端写入的文本一致
+type inputMapper struct {
+	cfg inputConfig
+	seq int64 // 未映射 id 时按读取顺序生成
+}
+
+func (m *inputMapper) order(get func(col string) (string, bool)) (Order, int64, error) {
+	cols := m.cfg.Columns
+	field := func(col string) (string, error) {
+		v, ok := get(col)
+		if !ok {
+			return "", fmt.Errorf("missing field %q", col)
+		}
+		return strings.TrimSpace(v), nil
+	}
+
+	m.seq++
+	order := Order{ID: m.seq}
+	var err error
+	if cols.ID != "" {
+		raw, err := field(cols.ID)
+		if err != nil {
+			return Order{}, 0, err
+		}
+		if order.ID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return Order{}, 0, fmt.Errorf("invalid id %q", raw)
+		}
+	}
+	if order.OrderID, err = field(cols.OrderID); err != nil {
+		return Order{}, 0, err
+	}
+	if order.CustomerID, err = field(cols.CustomerID); err != nil {
+		return Order{}, 0, err
+	}
+	rawAmount, err := field(cols.Amount)
+	if err != nil {
+		return Order{}, 0, err
+	}
+	units, err := parseAmountUnits(rawAmount)
+	if err != nil {
+		return Order{}, 0, err
+	}
+	order.Amount = new(big.Rat).SetFrac64(units, 1000000000).FloatString(amountScale)
+	rawTime, err := field(cols.CreateTime)
+	if err != nil {
+		return Order{}, 0, err
+	}
+	createTime, err := time.Parse(m.cfg.TimeLayout, rawTime)
+	if err != nil {
+		return Order{}, 0, fmt.Errorf("invalid create_time %q (layout %q)", rawTime, m.cfg.TimeLayout)
+	}
+	order.CreateTime = createTime.UTC().Format(createTimeLayout)
+	return order, units, nil
+}
+
+// parseAmountUnits 把金额文本转换为 1e-9 单位的整数，超过 9 位小数或超出 int64 范围时报错
+func parseAmountUnits(amount string) (int64, error) {
+	rat, ok := parseDecimal(amount)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	units := new(big.Rat).Mul(rat, amountScaleFactor)
+	if !units.IsInt() {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", amount, amountScale)
+	}
+	if !units.Num().IsInt64() {
+		return 0, fmt.Errorf("amount %q out of range", amount)
+	}
+	return units.Num().Int64(), nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readAllOrders(t *testing.T, c inputConfig) ([]Order, []int64) {
+	t.Helper()
+	if err := c.normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	src, err := openInputSource(c)
+	if err != nil {
+		t.Fatalf("open %s: %v", c.Path, err)
+	}
+	defer src.close()
+	var orders []Order
+	var units []int64
+	for {
+		o, u, err := src.next()
+		if err == io.EOF {
+			return orders, units
+		}
+		if err != nil {
+			t.Fatalf("read %s: %v", c.Path, err)
+		}
+		orders = append(orders, o)
+		units = append(units, u)
+	}
+}
+
+// 导出的 orders.csv / orders.ndjson 作为 -input 读回时应与生成的数据逐条一致
+func TestInputExportRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	orders, units := generateOrders(7, parseDistribution(t, testDistributions["skewed"]), 500)
+	exp, err := newExporter(dir, []string{exportCSV, exportNDJSON})
+	if err != nil {
+		t.Fatalf("newExporter: %v", err)
+	}
+	for _, o := range orders {
+		if err := exp.write(o); err != nil {
+			t.Fatalf("export: %v", err)
+		}
+	}
+	if err := exp.close(); err != nil {
+		t.Fatalf("close exporter: %v", err)
+	}
+
+	for _, name := range []string{"orders.csv", "orders.ndjson"} {
+		t.Run(name, func(t *testing.T) {
+			got, gotUnits := readAllOrders(t, inputConfig{Path: filepath.Join(dir, name), Columns: inputColumns{ID: "id"}})
+			if len(got) != len(orders) {
+				t.Fatalf("read %d orders, want %d", len(got), len(orders))
+			}
+			for i := range orders {
+				if got[i] != orders[i] || gotUnits[i] != units[i] {
+					t.Fatalf("row %d: got %+v (%d), want %+v (%d)", i, got[i], gotUnits[i], orders[i], units[i])
+				}
+			}
+		})
+	}
+}
+
+// 自定义列名、分隔符与时间格式的映射
+func TestInputColumnMapping(t *testing.T) {
+	dir := t.TempDir()
+	cols := inputColumns{OrderID: "no", CustomerID: "buyer", Amount: "total", CreateTime: "ts"}
+	want := []Order{
+		{ID: 1, OrderID: "A-1", CustomerID: "C-9", Amount: "12.500000000", CreateTime: "2023-05-01 02:03:04.000000"},
+		{ID: 2, OrderID: "A-2", CustomerID: "C-1", Amount: "0.000000001", CreateTime: "2023-05-01 23:59:59.000000"},
+	}
+	wantUnits := []int64{12500000000, 1}
+
+	csvPath := filepath.Join(dir, "in.csv")
+	csvData := "\ufeffts;buyer;extra;total;no\n" +
+		"2023-05-01T10:03:04+08:00;C-9;x;12.5;A-1\n" +
+		"2023-05-01T23:59:59Z; C-1 ;y;0.000000001;A-2\n"
+	if err := os.WriteFile(csvPath, []byte(csvData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ndjsonPath := filepath.Join(dir, "in.jsonl")
+	ndjsonData := `{"no":"A-1","buyer":"C-9","total":12.5,"ts":"2023-05-01T10:03:04+08:00"}` + "\n" +
+		`{"no":"A-2","buyer":"C-1","total":"0.000000001","ts":"2023-05-01T23:59:59Z"}` + "\n"
+	if err := os.WriteFile(ndjsonPath, []byte(ndjsonData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []inputConfig{
+		{Path: csvPath, Delimiter: ";", TimeLayout: "2006-01-02T15:04:05Z07:00", Columns: cols},
+		{Path: ndjsonPath, TimeLayout: "2006-01-02T15:04:05Z07:00", Columns: cols},
+	} {
+		got, gotUnits := readAllOrders(t, c)
+		if len(got) != len(want) {
+			t.Fatalf("%s: read %d orders, want %d", c.Path, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] || gotUnits[i] != wantUnits[i] {
+				t.Errorf("%s row %d: got %+v (%d), want %+v (%d)", c.Path, i, got[i], gotUnits[i], want[i], wantUnits[i])
+			}
+		}
+	}
+}
+
+func TestInputErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"missing.csv":   "order_id,customer_id,amount\nA,C,1\n",
+		"precision.csv": "order_id,customer_id,amount,create_time\nA,C,1.0000000001,2024-01-01 00:00:00.000000\n",
+		"time.csv":      "order_id,customer_id,amount,create_time\nA,C,1,2024/01/01\n",
+		"amount.ndjson": `{"order_id":"A","customer_id":"C","amount":"abc","create_time":"2024-01-01 00:00:00.000000"}` + "\n",
+	}
+	for name, data := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c := inputConfig{Path: path}
+		if err := c.normalize(); err != nil {
+			t.Fatalf("%s: normalize: %v", name, err)
+		}
+		src, err := openInputSource(c)
+		if err == nil {
+			_, _, err = src.next()
+			src.close()
+		}
+		if err == nil || err == io.EOF {
+			t.Errorf("%s: got %v, want a read error", name, err)
+		}
+	}
+
+	c := inputConfig{Path: "orders.txt"}
+	if err := c.normalize(); err == nil {
+		t.Error("normalize(orders.txt) = nil, want error for unknown format")
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// --- Phase 1 写入重试与汇总 ---
+// 每个批次写入失败时，可重试的订单按指数退避重试（-load-retries / -load-backoff），
+// 重试耗尽或不可重试的订单计为永久失败；Phase 1 结束时按后端打印 写入/失败/重试 汇总
+
+// bulkError 表示一批订单部分写入失败（如 ES bulk 中部分文档被拒绝）
+// retry 为可以重试的订单，permanent 为不可重试的失败行数，其余订单已写入成功
+type bulkError struct {
+	retry     []Order
+	permanent int
+	cause     error // 第一个失败项的错误，用于日志
+}
+
+func (e *bulkError) Error() string {
+	return fmt.Sprintf("%d retryable, %d permanent failure(s): %v", len(e.retry), e.permanent, e.cause)
+}
+
+func (e *bulkError) Unwrap() error { return e.cause }
+
+// loadStats 记录一个后端的写入统计，多个消费者并发累加
+type loadStats struct {
+	backend Backend
+	workers int
+	written atomic.Int64
+	failed  atomic.Int64
+	retried atomic.Int64  // 重试的订单次数（同一订单重试多次会重复计数）
+	elapsed time.Duration // 从开始加载到该后端写完（含 FlushLoad / FinishLoad）的耗时，done 之后才可读取
+	done    atomic.Bool
+	async   bool // 异步写入时写入结果由后端记录，见 asyncLoader
+
+	// BulkProcessor 整个 bulk 请求的重试次数，无法对应到行数，不计入 retried
+	requestRetries atomic.Int64
+
+	mu      sync.Mutex
+	batches *hdrhistogram.Histogram // 每次写入请求（含重试）的耗时
+}
+
+func newLoadStats(b Backend) *loadStats {
+	return &loadStats{backend: b, workers: loadWorkers(b.Name()), batches: newLatencyHistogram()}
+}
+
+func (st *loadStats) recordBatch(d time.Duration) {
+	st.mu.Lock()
+	recordLatency(st.batches, d)
+	st.mu.Unlock()
+}
+
+func (st *loadStats) batchStats() LatencyStats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return latencyStatsFrom(st.batches)
+}
+
+// --- 每个后端独立的写入管道 ---
+// 生产者只读取一次数据，把每个批次放入每个后端自己的队列（-load-queue 个批次），由该后端的消费者（-load-workers）写入；
+// 较快的后端可以领先较慢的后端最多一个队列的批次，各后端的耗时分别统计，生成与解析数据的开销不会按后端数重复计算
+
+// runLoadPipeline 启动 st.workers 个消费者写入 queue 中的批次，队列关闭并写完后执行 FlushLoad 与 FinishLoad
+func runLoadPipeline(st *loadStats, queue <-chan []Order, start time.Time) {
+	var wg sync.WaitGroup
+	for i := 0; i < st.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range queue {
+				loadBatch(st, batch)
+			}
+		}()
+	}
+	wg.Wait()
+
+	finishLoad(st)
+	st.elapsed = time.Since(start)
+	st.done.Store(true)
+}
+
+// startLoad 执行后端的加载前准备，并确定本次是否异步写入
+func startLoad(st *loadStats) error {
+	b := st.backend
+	if s, ok := b.(loadStarter); ok {
+		if err := s.StartLoad(); err != nil {
+			return err
+		}
+	}
+	if a, ok := b.(asyncLoader); ok {
+		async, err := a.AsyncLoad(st)
+		if err != nil {
+			return err
+		}
+		st.async = async
+	}
+	return nil
+}
+
+// finishLoad 等待异步写入完成并执行后端的收尾操作，加载中途出错时同样需要调用（如恢复 ES 写入调优前的设置）
+func finishLoad(st *loadStats) {
+	b := st.backend
+	if st.async {
+		if err := b.(asyncLoader).FlushLoad(); err != nil {
+			log.Printf("%s flush load failed: %v", engineLabel(b.Name()), err)
+		}
+	}
+	// 部分后端（如 ES）需要在加载完成后刷新，确保数据立即可查
+	if f, ok := b.(loadFinisher); ok {
+		if err := f.FinishLoad(); err != nil {
+			log.Printf("%s finish load failed: %v", engineLabel(b.Name()), err)
+		}
+	}
+}
+
+// parseLoadWorkers 解析 -load-workers：逗号分隔，不带后端名的值为默认并发数，
+// backend=n 为单个后端的并发数，如 "5"、"mysql=8,es=4"、"4,sqlite=1"
+func parseLoadWorkers(s string) (int, map[string]int, error) {
+	def := 5
+	perBackend := map[string]int{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok {
+			n, err = strconv.Atoi(part)
+		}
+		if err != nil || n < 1 {
+			return 0, nil, fmt.Errorf("%q must be a positive integer", part)
+		}
+		if !ok {
+			def = n
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := backendRegistry[name]; !known {
+			return 0, nil, fmt.Errorf("unknown backend %q (supported: %s)", name, strings.Join(backendNames(), ", "))
+		}
+		perBackend[name] = n
+	}
+	return def, perBackend, nil
+}
+
+// loadWorkers 返回后端 name 在 Phase 1 的消费者数
+func loadWorkers(name string) int {
+	if n, ok := cfg.LoadWorkers[name]; ok {
+		return n
+	}
+	return cfg.LoadWorkersDefault
+}
+
+// describeLoadWorkers 返回 -load-workers 的规范形式，用于启动日志
+func describeLoadWorkers() string {
+	parts := []string{strconv.Itoa(cfg.LoadWorkersDefault)}
+	names := make([]string, 0, len(cfg.LoadWorkers))
+	for name := range cfg.LoadWorkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, cfg.LoadWorkers[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// loadBatch 写入一批订单，按错误类型重试，直到全部写入、永久失败或重试耗尽
+func loadBatch(st *loadStats, batch []Order) {
+	b := st.backend
+	if st.async {
+		// 异步写入：Load 只负责入队，写入结果与请求耗时由后端在请求完成时记录
+		if err := b.Load(batch); err != nil {
+			st.failed.Add(int64(len(batch)))
+			log.Printf("%s Write Error: %d row(s) failed: %v", engineLabel(b.Name()), len(batch), err)
+		}
+		return
+	}
+	pending := batch
+	for attempt := 0; ; attempt++ {
+		t := time.Now()
+		err := b.Load(pending)
+		st.recordBatch(time.Since(t))
+		if err == nil {
+			st.written.Add(int64(len(pending)))
+			return
+		}
+		var retry []Order
+		var be *bulkError
+		switch {
+		case errors.As(err, &be):
+			st.written.Add(int64(len(pending) - len(be.retry) - be.permanent))
+			if be.permanent > 0 {
+				st.failed.Add(int64(be.permanent))
+				log.Printf("%s Write Error: %d row(s) rejected: %v", engineLabel(b.Name()), be.permanent, be.cause)
+			}
+			retry = be.retry
+		case loadRetryable(b, err):
+			retry = pending
+		default:
+			st.failed.Add(int64(len(pending)))
+			log.Printf("%s Write Error: %d row(s) failed: %v", engineLabel(b.Name()), len(pending), err)
+			return
+		}
+		if len(retry) == 0 {
+			return
+		}
+		if attempt >= cfg.LoadRetries {
+			st.failed.Add(int64(len(retry)))
+			log.Printf("%s Write Error: %d row(s) failed after %d retries: %v", engineLabel(b.Name()), len(retry), cfg.LoadRetries, err)
+			return
+		}
+		st.retried.Add(int64(len(retry)))
+		time.Sleep(loadBackoff(attempt))
+		pending = retry
+	}
+}
+
+func loadRetryable(b Backend, err error) bool {
+	r, ok := b.(loadRetrier)
+	return ok && r.Retryable(err)
+}
+
+// loadBackoff 返回第 attempt 次重试前的等待时间：-load-backoff * 2^attempt，最长 30s
+func loadBackoff(attempt int) time.Duration {
+	const maxBackoff = 30 * time.Second
+	d := cfg.LoadBackoff
+	for i := 0; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// --- 加载进度（-load-progress） ---
+
+// startLoadProgress 每隔 -load-progress 打印各后端已处理（写入 + 失败）的行数、平均速率与预计剩余时间，
+// expected 为 0（外部文件行数未知）时不显示百分比与剩余时间；返回的函数停止打印
+func startLoadProgress(stats []*loadStats, expected int, start time.Time) func() {
+	if cfg.LoadProgress <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(cfg.LoadProgress)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				printLoadProgress(stats, expected, time.Since(start))
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func printLoadProgress(stats []*loadStats, expected int, elapsed time.Duration) {
+	fmt.Printf(">>> [Phase 1] 进度 (%v):\n", elapsed.Round(time.Second))
+	for _, st := range stats {
+		label := engineLabel(st.backend.Name())
+		if st.done.Load() {
+			fmt.Printf("    %-8s | 已完成 | 耗时: %v\n", label, st.elapsed.Round(time.Millisecond))
+			continue
+		}
+		rows := st.written.Load() + st.failed.Load()
+		rate := float64(rows) / elapsed.Seconds()
+		if expected <= 0 {
+			fmt.Printf("    %-8s | %d 行 | %.0f 行/s\n", label, rows, rate)
+			continue
+		}
+		eta := "未知"
+		if rate > 0 {
+			remaining := float64(int64(expected) - rows)
+			eta = time.Duration(remaining / rate * float64(time.Second)).Round(100 * time.Millisecond).String()
+		}
+		fmt.Printf("    %-8s | %d/%d (%.1f%%) | %.0f 行/s | ETA: %s\n",
+			label, rows, expected, float64(rows)/float64(expected)*100, rate, eta)
+	}
+}
+
+// rowsPerSec 返回该后端的写入速率（成功写入的行数 / 耗时）
+func (st *loadStats) rowsPerSec() float64 {
+	if st.elapsed <= 0 {
+		return 0
+	}
+	return float64(st.written.Load()) / st.elapsed.Seconds()
+}
+
+// printLoadSummary 打印各后端的写入汇总，返回写入行数与 expected 不一致的后端
+func printLoadSummary(stats []*loadStats, expected int) []string {
+	fmt.Println(">>> [Phase 1] 写入汇总:")
+	var incomplete []string
+	for _, st := range stats {
+		written := st.written.Load()
+		fmt.Printf("    %-8s | 并发: %-3d | 写入: %-10d | 失败: %-8d | 重试: %-8d | 耗时: %-12v | %.0f 行/s",
+			engineLabel(st.backend.Name()), st.workers, written, st.failed.Load(), st.retried.Load(),
+			st.elapsed.Round(time.Millisecond), st.rowsPerSec())
+		if method := loadMethod(st.backend); method != "" {
+			fmt.Printf(" | 方式: %s", method)
+		}
+		if n := st.requestRetries.Load(); n > 0 {
+			fmt.Printf(" | 整请求重试: %d 次（不计入重试行数）", n)
+		}
+		fmt.Println()
+		fmt.Printf("             批次耗时: %s\n", st.batchStats())
+		if written != int64(expected) {
+			incomplete = append(incomplete, fmt.Sprintf("%s %d/%d", st.backend.Name(), written, expected))
+		}
+	}
+	return incomplete
+}
+
+// loadMethod 返回后端的写入方式，不区分写入方式的后端返回空字符串
+func loadMethod(b Backend) string {
+	if m, ok := b.(loadMethoder); ok {
+		return m.LoadMethod()
+	}
+	return ""
+}
+
+// --- 加载结果（写入报告） ---
+
+// IngestStats 是 Phase 1 单个后端的写入统计
+type IngestStats struct {
+	Method         string       `json:"method,omitempty"` // 写入方式，如 MySQL 的 -mysql-load-method、ES 的 -es-writer
+	Workers        int          `json:"workers"`
+	Written        int64        `json:"written"`
+	Failed         int64        `json:"failed"`
+	Retried        int64        `json:"retried"`
+	RequestRetries int64        `json:"request_retries,omitempty"` // BulkProcessor 整个请求的重试次数（按请求计，不计入 Retried）
+	RowsPerSec     float64      `json:"rows_per_sec"`
+	Batches        LatencyStats `json:"batches"` // 每次 Load 调用（含重试）的耗时分布
+}
+
+func (s IngestStats) String() string {
+	line := fmt.Sprintf("Workers=%d | Written=%d | Failed=%d | Retried=%d | Rows/s=%.0f",
+		s.Workers, s.Written, s.Failed, s.Retried, s.RowsPerSec)
+	if s.Method != "" {
+		line += " | Method=" + s.Method
+	}
+	if s.RequestRetries > 0 {
+		line += fmt.Sprintf(" | RequestRetries=%d", s.RequestRetries)
+	}
+	return line
+}
+
+// loadResults 把各后端的写入统计转换为 load 场景的结果，Duration 为该后端的写入耗时
+func loadResults(stats []*loadStats) []Result {
+	results := make([]Result, 0, len(stats))
+	for _, st := range stats {
+		r := Result{
+			Scenario: "load",
+			Query:    "load",
+			Engine:   st.backend.Name(),
+			Type:     "Ingest",
+			Duration: st.elapsed,
+			Rows:     st.written.Load(),
+			Ingest: &IngestStats{
+				Method:         loadMethod(st.backend),
+				Workers:        st.workers,
+				Written:        st.written.Load(),
+				Failed:         st.failed.Load(),
+				Retried:        st.retried.Load(),
+				RequestRetries: st.requestRetries.Load(),
+				RowsPerSec:     st.rowsPerSec(),
+				Batches:        st.batchStats(),
+			},
+		}
+		if r.Engine == "es" {
+			r.AmountType = cfg.ESAmountType
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errTestRetryable = errors.New("deadlock")
+	errTestFatal     = errors.New("syntax error")
+)
+
+// fakeLoadBackend 依次返回 results 中的错误（用完后返回 nil），并记录每次 Load 的行数
+type fakeLoadBackend struct {
+	results []func(batch []Order) error
+	calls   []int
+}
+
+func (b *fakeLoadBackend) Name() string          { return "fake" }
+func (b *fakeLoadBackend) Open() error           { return nil }
+func (b *fakeLoadBackend) Exists() (bool, error) { return false, nil }
+func (b *fakeLoadBackend) InitSchema() error     { return nil }
+func (b *fakeLoadBackend) Sum(limit int) Result  { return Result{} }
+func (b *fakeLoadBackend) Close() error          { return nil }
+
+func (b *fakeLoadBackend) Load(batch []Order) error {
+	b.calls = append(b.calls, len(batch))
+	if len(b.calls) > len(b.results) {
+		return nil
+	}
+	return b.results[len(b.calls)-1](batch)
+}
+
+func (b *fakeLoadBackend) Retryable(err error) bool { return errors.Is(err, errTestRetryable) }
+
+func fail(err error) func([]Order) error {
+	return func([]Order) error { return err }
+}
+
+// rejectFirst 让前 retry 行可重试、随后 permanent 行永久失败，其余写入成功
+func rejectFirst(retry, permanent int) func([]Order) error {
+	return func(batch []Order) error {
+		return &bulkError{retry: batch[:retry], permanent: permanent, cause: errTestFatal}
+	}
+}
+
+func TestLoadBatchRetryAccounting(t *testing.T) {
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+	cfg.LoadRetries = 3
+	cfg.LoadBackoff = time.Microsecond
+
+	tests := []struct {
+		name    string
+		results []func([]Order) error
+		calls   []int
+		written int64
+		failed  int64
+		retried int64
+	}{
+		{"success", nil, []int{10}, 10, 0, 0},
+		{"partial then success", []func([]Order) error{rejectFirst(2, 1)}, []int{10, 2}, 9, 1, 2},
+		{"partial retried twice", []func([]Order) error{rejectFirst(4, 0), rejectFirst(1, 1)}, []int{10, 4, 1}, 9, 1, 5},
+		{"all permanent", []func([]Order) error{rejectFirst(0, 10)}, []int{10}, 0, 10, 0},
+		{"retryable then success", []func([]Order) error{fail(errTestRetryable)}, []int{10, 10}, 10, 0, 10},
+		{"retries exhausted", []func([]Order) error{
+			fail(errTestRetryable), fail(errTestRetryable), fail(errTestRetryable), fail(errTestRetryable),
+		}, []int{10, 10, 10, 10}, 0, 10, 30},
+		{"not retryable", []func([]Order) error{fail(errTestFatal)}, []int{10}, 0, 10, 0},
+		{"retryable then not retryable", []func([]Order) error{fail(errTestRetryable), fail(errTestFatal)}, []int{10, 10}, 0, 10, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &fakeLoadBackend{results: tt.results}
+			st := newLoadStats(b)
+			loadBatch(st, make([]Order, 10))
+			if len(b.calls) != len(tt.calls) {
+				t.Fatalf("Load calls = %v, want %v", b.calls, tt.calls)
+			}
+			for i := range tt.calls {
+				if b.calls[i] != tt.calls[i] {
+					t.Fatalf("Load calls = %v, want %v", b.calls, tt.calls)
+				}
+			}
+			if st.written.Load() != tt.written || st.failed.Load() != tt.failed || st.retried.Load() != tt.retried {
+				t.Errorf("written/failed/retried = %d/%d/%d, want %d/%d/%d",
+					st.written.Load(), st.failed.Load(), st.retried.Load(), tt.written, tt.failed, tt.retried)
+			}
+			if n := st.batchStats().Count; n != int64(len(tt.calls)) {
+				t.Errorf("batch histogram has %d samples, want %d", n, len(tt.calls))
+			}
+		})
+	}
+}
+
+func TestLoadBackoff(t *testing.T) {
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+	cfg.LoadBackoff = 200 * time.Millisecond
+	for attempt, want := range []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond} {
+		if got := loadBackoff(attempt); got != want {
+			t.Errorf("loadBackoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	if got := loadBackoff(20); got != 30*time.Second {
+		t.Errorf("loadBackoff(20) = %v, want 30s", got)
+	}
+}
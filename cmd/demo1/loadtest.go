@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- 并发压测统计 ---
+type LoadStats struct {
+	Clients    int           `json:"clients"`
+	Requests   int64         `json:"requests"`
+	Errors     int64         `json:"errors"`
+	ErrorRate  float64       `json:"error_rate"`
+	Throughput float64       `json:"qps"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	LastError  string        `json:"last_error,omitempty"`
+}
+
+func (s LoadStats) String() string {
+	return fmt.Sprintf("Clients=%d | Requests=%d | Errors=%d (%.2f%%) | QPS=%.1f | Elapsed=%v",
+		s.Clients, s.Requests, s.Errors, s.ErrorRate*100, s.Throughput, s.Elapsed.Round(time.Millisecond))
+}
+
+// loadModeEnabled 指定了 -clients 时进入并发压测模式
+func loadModeEnabled() bool {
+	return cfg.Clients > 0
+}
+
+// runLoad 使用 cfg.Clients 个 goroutine 并发执行同一查询，
+// 直到达到 cfg.Requests 次请求（若指定）或持续 cfg.Duration
+func runLoad(run func() Result) Result {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		issued   int64
+		errCount int64
+		last     Result
+		lastFail Result
+	)
+	merged := newLatencyHistogram()
+	deadline := time.Now().Add(cfg.Duration)
+
+	// 预留一个请求名额；按请求数压测时超过上限即停止
+	acquire := func() bool {
+		if cfg.Requests > 0 {
+			return atomic.AddInt64(&issued, 1) <= cfg.Requests
+		}
+		return time.Now().Before(deadline)
+	}
+
+	start := time.Now()
+	for i := 0; i < cfg.Clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := newLatencyHistogram()
+			var ok Result
+			for acquire() {
+				res := run()
+				if res.Error != "" {
+					atomic.AddInt64(&errCount, 1)
+					mu.Lock()
+					lastFail = res
+					mu.Unlock()
+					continue
+				}
+				recordLatency(h, res.Duration)
+				ok = res
+			}
+			mu.Lock()
+			merged.Merge(h)
+			if ok.Scenario != "" {
+				last = ok
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	succeeded := merged.TotalCount()
+	load := LoadStats{
+		Clients:   cfg.Clients,
+		Requests:  succeeded + errCount,
+		Errors:    errCount,
+		Elapsed:   elapsed,
+		LastError: lastFail.Error,
+	}
+	if load.Requests > 0 {
+		load.ErrorRate = float64(errCount) / float64(load.Requests)
+	}
+	if elapsed > 0 {
+		load.Throughput = float64(succeeded) / elapsed.Seconds()
+	}
+
+	// 全部失败时没有可用的查询结果，以最后一次错误结果返回
+	if succeeded == 0 {
+		lastFail.Load = &load
+		return lastFail
+	}
+
+	stats := latencyStatsFrom(merged)
+	last.Duration = stats.Mean
+	last.Stats = &stats
+	last.Load = &load
+	return last
+}
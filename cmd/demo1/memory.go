@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"runtime/metrics"
+	"time"
+)
+
+// --- 内存统计 ---
+// 进程级指标：同一轮中不同引擎的场景并发执行，需要隔离时用 -scenarios 单独运行
+type MemoryStats struct {
+	HeapPeak  uint64 `json:"heap_peak_bytes"` // 执行期间采样到的堆上对象占用峰值
+	Allocated uint64 `json:"alloc_bytes"`     // 执行期间累计分配的字节数
+}
+
+const (
+	metricHeapObjects = "/memory/classes/heap/objects:bytes"
+	metricHeapAllocs  = "/gc/heap/allocs:bytes"
+
+	memorySampleInterval = 2 * time.Millisecond
+)
+
+func readMemoryMetrics() (heap, allocs uint64) {
+	samples := []metrics.Sample{{Name: metricHeapObjects}, {Name: metricHeapAllocs}}
+	metrics.Read(samples)
+	return samples[0].Value.Uint64(), samples[1].Value.Uint64()
+}
+
+// measureMemory 执行 run 并在后台定期采样堆占用，将峰值与累计分配量写入结果
+// runtime/metrics 的读取不会 stop-the-world，采样本身对耗时影响很小
+func measureMemory(run func() Result) Result {
+	peak, startAllocs := readMemoryMetrics()
+	stop := make(chan struct{})
+	done := make(chan uint64)
+	go func() {
+		ticker := time.NewTicker(memorySampleInterval)
+		defer ticker.Stop()
+		max := peak
+		for {
+			select {
+			case <-ticker.C:
+				if heap, _ := readMemoryMetrics(); heap > max {
+					max = heap
+				}
+			case <-stop:
+				done <- max
+				return
+			}
+		}
+	}()
+
+	res := run()
+	close(stop)
+	max := <-done
+	heap, allocs := readMemoryMetrics()
+	if heap > max {
+		max = heap
+	}
+	res.Memory = &MemoryStats{HeapPeak: max, Allocated: allocs - startAllocs}
+	return res
+}
+
+// formatBytes 以 KiB/MiB/GiB 显示字节数
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (m MemoryStats) String() string {
+	return fmt.Sprintf("HeapPeak=%s | Alloc=%s", formatBytes(m.HeapPeak), formatBytes(m.Allocated))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// --- MySQL 写入方式（-mysql-load-method） ---
+// multi:    每个批次拼接一条多行 INSERT（默认），驱动每次执行都会重新 prepare
+// prepared: 多行 INSERT 只 prepare 一次，行数为 -batch 的批次复用同一语句（其余批次退回 multi）
+// tx:       每个批次开启一个事务，逐行执行预编译的单行 INSERT 后提交
+// loaddata: 每个批次通过驱动的 Reader 处理器以 LOAD DATA LOCAL INFILE 发送（需服务端开启 local_infile）
+const (
+	mysqlLoadMulti    = "multi"
+	mysqlLoadPrepared = "prepared"
+	mysqlLoadTx       = "tx"
+	mysqlLoadData     = "loaddata"
+)
+
+var mysqlLoadMethods = []string{mysqlLoadMulti, mysqlLoadPrepared, mysqlLoadTx, mysqlLoadData}
+
+func validMySQLLoadMethod(m string) bool {
+	for _, v := range mysqlLoadMethods {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
+
+// mysqlInsertSQL 返回 rows 行的多行 INSERT 语句
+func mysqlInsertSQL(rows int) string {
+	return "INSERT INTO customer_orders (id, order_id, customer_id, amount, create_time) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?),", rows), ",")
+}
+
+func mysqlInsertArgs(orders []Order) []interface{} {
+	vals := make([]interface{}, 0, len(orders)*5)
+	for _, o := range orders {
+		vals = append(vals, o.ID, o.OrderID, o.CustomerID, o.Amount, o.CreateTime)
+	}
+	return vals
+}
+
+func (b *mysqlBackend) loadMulti(orders []Order) error {
+	_, err := b.db.Exec(mysqlInsertSQL(len(orders)), mysqlInsertArgs(orders)...)
+	return err
+}
+
+func (b *mysqlBackend) loadPrepared(orders []Order) error {
+	if len(orders) != cfg.Batch {
+		return b.loadMulti(orders)
+	}
+	stmt, err := b.batchStmt()
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(mysqlInsertArgs(orders)...)
+	return err
+}
+
+// batchStmt 返回 -batch 行的预编译 INSERT，首次调用时 prepare，Close 时释放
+func (b *mysqlBackend) batchStmt() (*sql.Stmt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stmt == nil {
+		stmt, err := b.db.Prepare(mysqlInsertSQL(cfg.Batch))
+		if err != nil {
+			return nil, err
+		}
+		b.stmt = stmt
+	}
+	return b.stmt, nil
+}
+
+func (b *mysqlBackend) loadTx(orders []Order) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(mysqlInsertSQL(1))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, o := range orders {
+		if _, err := stmt.Exec(o.ID, o.OrderID, o.CustomerID, o.Amount, o.CreateTime); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// 每个批次注册一个唯一名称的 Reader 处理器
+var mysqlReaderSeq atomic.Int64
+
+// loadDataInfile 以 LOAD DATA LOCAL INFILE 写入一批订单
+// LOCAL 模式下主键冲突与数据错误只产生警告并跳过该行，因此按影响行数计算被跳过的行
+func (b *mysqlBackend) loadDataInfile(orders []Order) error {
+	var data []byte
+	for _, o := range orders {
+		data = appendLoadDataRow(data, o)
+	}
+	name := "demo1-" + strconv.FormatInt(mysqlReaderSeq.Add(1), 10)
+	mysql.RegisterReaderHandler(name, func() io.Reader { return bytes.NewReader(data) })
+	defer mysql.DeregisterReaderHandler(name)
+
+	res, err := b.db.Exec("LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE customer_orders " +
+		"CHARACTER SET utf8mb4 (id, order_id, customer_id, amount, create_time)")
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if skipped := len(orders) - int(n); skipped > 0 {
+		return &bulkError{permanent: skipped, cause: fmt.Errorf("LOAD DATA skipped %d row(s) (duplicate key or invalid data)", skipped)}
+	}
+	return nil
+}
+
+// appendLoadDataRow 以 LOAD DATA 默认格式（制表符分隔、反斜杠转义）追加一行
+func appendLoadDataRow(dst []byte, o Order) []byte {
+	dst = strconv.AppendInt(dst, o.ID, 10)
+	for _, v := range []string{o.OrderID, o.CustomerID, o.Amount, o.CreateTime} {
+		dst = append(dst, '\t')
+		dst = append(dst, mysqlLoadDataEscaper.Replace(v)...)
+	}
+	return append(dst, '\n')
+}
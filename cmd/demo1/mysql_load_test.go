@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendLoadDataRow(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		want  string
+	}{
+		{
+			"plain",
+			Order{ID: 1, OrderID: "ORD-1-0", CustomerID: "CUST-7", Amount: "12.500000000", CreateTime: "2024-01-01 00:00:00.000000"},
+			"1\tORD-1-0\tCUST-7\t12.500000000\t2024-01-01 00:00:00.000000\n",
+		},
+		{
+			"tab newline backslash",
+			Order{ID: 42, OrderID: "A\tB", CustomerID: "C\nD", Amount: "1", CreateTime: `E\F`},
+			"42\tA\\tB\tC\\nD\t1\tE\\\\F\n",
+		},
+		{
+			"carriage return and NUL",
+			Order{ID: 3, OrderID: "x\r", CustomerID: "y\x00", Amount: "0", CreateTime: ""},
+			"3\tx\\r\ty\\0\t0\t\n",
+		},
+		{
+			// 已转义形式的文本必须再次转义，否则 LOAD DATA 会把它还原为控制字符
+			"escaped text",
+			Order{ID: 4, OrderID: `\t`, CustomerID: `\\n`, Amount: "0", CreateTime: "t"},
+			"4\t\\\\t\t\\\\\\\\n\t0\tt\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(appendLoadDataRow(nil, tt.order))
+			if got != tt.want {
+				t.Fatalf("appendLoadDataRow = %q, want %q", got, tt.want)
+			}
+			// 每行恰好 5 个字段、以换行结束，字段内不能残留未转义的分隔符
+			fields := strings.Split(strings.TrimSuffix(got, "\n"), "\t")
+			if len(fields) != 5 || strings.Count(got, "\n") != 1 {
+				t.Fatalf("row %q does not have 5 tab-separated fields on one line", got)
+			}
+			for i, v := range []string{tt.order.OrderID, tt.order.CustomerID, tt.order.Amount, tt.order.CreateTime} {
+				if u := unescapeLoadData(fields[i+1]); u != v {
+					t.Errorf("field %d unescapes to %q, want %q", i+1, u, v)
+				}
+			}
+		})
+	}
+}
+
+func TestAppendLoadDataRowAppends(t *testing.T) {
+	var buf []byte
+	buf = appendLoadDataRow(buf, Order{ID: 1, OrderID: "a", CustomerID: "b", Amount: "1", CreateTime: "t"})
+	buf = appendLoadDataRow(buf, Order{ID: 2, OrderID: "c", CustomerID: "d", Amount: "2", CreateTime: "u"})
+	if want := "1\ta\tb\t1\tt\n2\tc\td\t2\tu\n"; string(buf) != want {
+		t.Errorf("buffer = %q, want %q", buf, want)
+	}
+}
+
+// unescapeLoadData 按 LOAD DATA 默认的 ESCAPED BY '\\' 规则还原字段
+func unescapeLoadData(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func TestMySQLInsertSQL(t *testing.T) {
+	want := "INSERT INTO customer_orders (id, order_id, customer_id, amount, create_time) VALUES (?, ?, ?, ?, ?),(?, ?, ?, ?, ?)"
+	if got := mysqlInsertSQL(2); got != want {
+		t.Errorf("mysqlInsertSQL(2) = %q, want %q", got, want)
+	}
+	args := mysqlInsertArgs([]Order{{ID: 1, OrderID: "a"}, {ID: 2, OrderID: "b"}})
+	if len(args) != 10 || args[0] != int64(1) || args[1] != "a" || args[5] != int64(2) || args[6] != "b" {
+		t.Errorf("mysqlInsertArgs = %v", args)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// --- 内存参考引擎 ---
+// Phase 1 生成（或从 -input 读取）数据时按 id 记录每条订单的金额（单位 1e-9 的整数），
+// 任一数据规模的 sum_top_n 都可以精确算出期望值，作为一致性校验的基准
+const referenceEngine = "reference"
+
+type referenceStore struct {
+	units []int64 // units[i] 为 id=i+1 的订单金额 * 1e9
+	err   error   // 记录的数据不满足 id 连续递增时不可用
+}
+
+func newReferenceStore(capacity int) *referenceStore {
+	return &referenceStore{units: make([]int64, 0, capacity)}
+}
+
+// record 记录一条订单，id 必须从 1 开始连续递增
+func (r *referenceStore) record(id int64, units int64) {
+	if r.err != nil {
+		return
+	}
+	if id != int64(len(r.units))+1 {
+		r.err = fmt.Errorf("order id %d is not contiguous (expected %d)", id, len(r.units)+1)
+		return
+	}
+	r.units = append(r.units, units)
+}
+
+// available 判断参考值是否可用，不可用时返回原因
+func (r *referenceStore) available() error {
+	if r == nil {
+		return fmt.Errorf("数据未在本次运行中生成")
+	}
+	return r.err
+}
+
+// sum 返回按 id 升序前 limit 条（0 表示全部）的金额总和与行数
+func (r *referenceStore) sum(limit int) (string, int64) {
+	n := len(r.units)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	// 先在 int64 中累加，即将溢出时再并入 big.Int（-input 的金额不受生成器上限约束）
+	total := new(big.Int)
+	var acc int64
+	for i := 0; i < n; i++ {
+		u := r.units[i]
+		if (u > 0 && acc > math.MaxInt64-u) || (u < 0 && acc < math.MinInt64-u) {
+			total.Add(total, big.NewInt(acc))
+			acc = 0
+		}
+		acc += u
+	}
+	total.Add(total, big.NewInt(acc))
+	return new(big.Rat).SetFrac(total, amountScaleFactor.Num()).FloatString(amountScale), int64(n)
+}
+
+// result 将期望值包装为校验基准
+func (r *referenceStore) result(limit int) Result {
+	sum, rows := r.sum(limit)
+	return Result{Scenario: "expected", Query: querySumTopN, Engine: referenceEngine, Limit: limit, Sum: sum, Rows: rows}
+}
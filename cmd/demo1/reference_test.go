@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReferenceStoreSum(t *testing.T) {
+	r := newReferenceStore(4)
+	for i, u := range []int64{1500000000, 1, 999999999, 2000000000} {
+		r.record(int64(i+1), u)
+	}
+	if err := r.available(); err != nil {
+		t.Fatalf("available: %v", err)
+	}
+	tests := []struct {
+		limit int
+		sum   string
+		rows  int64
+	}{
+		{0, "4.500000000", 4},
+		{1, "1.500000000", 1},
+		{2, "1.500000001", 2},
+		{3, "2.500000000", 3},
+		{10, "4.500000000", 4},
+	}
+	for _, tt := range tests {
+		sum, rows := r.sum(tt.limit)
+		if sum != tt.sum || rows != tt.rows {
+			t.Errorf("sum(%d) = %s, %d; want %s, %d", tt.limit, sum, rows, tt.sum, tt.rows)
+		}
+	}
+}
+
+func TestReferenceStoreSumOverflow(t *testing.T) {
+	r := newReferenceStore(3)
+	r.record(1, math.MaxInt64)
+	r.record(2, math.MaxInt64)
+	r.record(3, -1)
+	// 2 * (2^63 - 1) - 1 = 18446744073709551613（单位 1e-9）
+	if sum, _ := r.sum(0); sum != "18446744073.709551613" {
+		t.Errorf("sum(0) = %s, want 18446744073.709551613", sum)
+	}
+}
+
+func TestReferenceStoreNonContiguous(t *testing.T) {
+	r := newReferenceStore(2)
+	r.record(1, 1)
+	r.record(3, 1)
+	if r.available() == nil {
+		t.Fatal("available() = nil, want error for non-contiguous ids")
+	}
+	var nilStore *referenceStore
+	if nilStore.available() == nil {
+		t.Error("available() on nil store = nil, want error")
+	}
+}
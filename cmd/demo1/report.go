@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 测试结果模型 ---
+type Result struct {
+	Scenario   string        `json:"scenario"`
+	Query      string        `json:"query"`                    // 场景计算的逻辑问题，相同 Query 与 Limit 的结果应当一致
+	Engine     string        `json:"engine"`                   // mysql, es
+	AmountType string        `json:"es_amount_type,omitempty"` // ES amount 存储策略
+	Type       string        `json:"type"`
+	Limit      int           `json:"limit"` // 0 表示全量
+	Duration   time.Duration `json:"duration_ns"`
+	Sum        string        `json:"sum"`
+	Rows       int64         `json:"rows"`
+	Note       string        `json:"note,omitempty"` // 仅用于表格输出的补充说明
+	Error      string        `json:"error,omitempty"`
+	Stats      *LatencyStats `json:"stats,omitempty"`  // 多次迭代时的延迟分布
+	Load       *LoadStats    `json:"load,omitempty"`   // 并发压测统计
+	Groups     []Group       `json:"groups,omitempty"` // 分组聚合结果（按顺序）
+	Pages      *LatencyStats `json:"pages,omitempty"`  // 分页场景每页的耗时分布
+	Memory     *MemoryStats  `json:"memory,omitempty"` // 执行期间的内存占用
+	Scan       *RowScanStats `json:"scan,omitempty"`   // 行流式拉取的吞吐与传输量
+	Ingest     *IngestStats  `json:"ingest,omitempty"` // Phase 1 数据加载统计（load 场景）
+}
+
+// MarshalJSON 额外输出毫秒耗时，便于直接对比
+func (r Result) MarshalJSON() ([]byte, error) {
+	type alias Result
+	return json.Marshal(struct {
+		alias
+		DurationMs float64 `json:"duration_ms"`
+	}{alias(r), float64(r.Duration) / float64(time.Millisecond)})
+}
+
+// --- 测试报告 ---
+type Report struct {
+	Version      string    `json:"version"`
+	CommitID     string    `json:"commit_id"`
+	StartedAt    time.Time `json:"started_at"`
+	Mode         string    `json:"mode"`
+	Total        int       `json:"total"`
+	Seed         int64     `json:"seed,omitempty"` // 仅本次运行生成数据时记录
+	DataReused   bool      `json:"data_reused,omitempty"`
+	Distribution string    `json:"distribution,omitempty"`
+	Input        string    `json:"input,omitempty"`
+	Results      []Result  `json:"results"`
+
+	Consistency []ConsistencyCheck `json:"consistency,omitempty"`
+}
+
+// 支持的报告格式
+var outputFormats = []string{"table", "json", "csv"}
+
+func validOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// 引擎在表格输出中的显示名称
+var engineLabels = map[string]string{
+	"mysql": "MySQL",
+	"es":    "ES",
+}
+
+func engineLabel(engine string) string {
+	if label, ok := engineLabels[engine]; ok {
+		return label
+	}
+	return engine
+}
+
+func limitLabel(limit int) string {
+	if limit == 0 {
+		return "ALL"
+	}
+	return strconv.Itoa(limit)
+}
+
+// formatResultLine 生成与历史输出一致的单行文本
+func formatResultLine(r Result) string {
+	prefix := fmt.Sprintf("[%-6s] Scenario=%s | Limit=%-8s | Type=%-11s", engineLabel(r.Engine), r.Scenario, limitLabel(r.Limit), r.Type)
+	if r.Error != "" {
+		line := fmt.Sprintf("%s | Error=%s", prefix, r.Error)
+		if r.Load != nil {
+			line += "\n         Load:    " + r.Load.String()
+		}
+		return line
+	}
+	if r.Ingest != nil {
+		return fmt.Sprintf("%s | Time=%-12s | Rows=%d\n         Ingest:  %s\n         Batches: %s",
+			prefix, r.Duration.Round(time.Millisecond), r.Rows, r.Ingest, r.Ingest.Batches)
+	}
+	line := fmt.Sprintf("%s | Time=%-12s | Sum=%s", prefix, r.Duration, r.Sum)
+	if r.Note != "" {
+		line += " " + r.Note
+	}
+	if len(r.Groups) > 0 {
+		line += "\n         Groups:  " + formatGroups(r.Groups, 5)
+	}
+	if r.Pages != nil {
+		line += "\n         Pages:   " + r.Pages.String()
+	}
+	if r.Scan != nil {
+		line += "\n         Scan:    " + r.Scan.String()
+	}
+	if r.Stats != nil {
+		line += "\n         Latency: " + r.Stats.String()
+	}
+	if r.Memory != nil {
+		line += "\n         Memory:  " + r.Memory.String()
+	}
+	if r.Load != nil {
+		line += "\n         Load:    " + r.Load.String()
+	}
+	return line
+}
+
+// formatGroups 格式化分组结果，max>0 时最多显示 max 组
+func formatGroups(groups []Group, max int) string {
+	parts := make([]string, 0, len(groups))
+	for i, g := range groups {
+		if max > 0 && i == max {
+			parts = append(parts, fmt.Sprintf("...(%d more)", len(groups)-max))
+			break
+		}
+		parts = append(parts, g.Key+"="+g.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// resultCollector 并发安全地收集结果，并实时打印表格行
+type resultCollector struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (c *resultCollector) add(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, r)
+	fmt.Println(formatResultLine(r))
+}
+
+func (c *resultCollector) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.results)
+}
+
+// since 返回第 n 条之后收集到的结果
+func (c *resultCollector) since(n int) []Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Result, len(c.results)-n)
+	copy(out, c.results[n:])
+	return out
+}
+
+func (c *resultCollector) all() []Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Result, len(c.results))
+	copy(out, c.results)
+	return out
+}
+
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// writeReport 按指定格式输出报告
+func writeReport(w io.Writer, format string, report Report) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"scenario", "query", "engine", "es_amount_type", "type", "limit", "duration_ms", "sum", "rows", "error",
+			"iterations", "min_ms", "p50_ms", "p95_ms", "p99_ms", "max_ms", "mean_ms", "stddev_ms",
+			"clients", "requests", "errors", "qps", "groups",
+			"pages", "page_p50_ms", "page_p99_ms", "page_max_ms", "heap_peak_bytes", "alloc_bytes",
+			"rows_per_sec", "bytes", "load_method", "workers", "written", "failed", "retried", "batch_p50_ms", "batch_p99_ms", "batch_max_ms",
+			"request_retries"})
+		for _, r := range report.Results {
+			row := []string{
+				r.Scenario,
+				r.Query,
+				r.Engine,
+				r.AmountType,
+				r.Type,
+				strconv.Itoa(r.Limit),
+				formatMs(r.Duration),
+				r.Sum,
+				strconv.FormatInt(r.Rows, 10),
+				r.Error,
+			}
+			if s := r.Stats; s != nil {
+				row = append(row, strconv.FormatInt(s.Count, 10), formatMs(s.Min), formatMs(s.P50), formatMs(s.P95),
+					formatMs(s.P99), formatMs(s.Max), formatMs(s.Mean), formatMs(s.StdDev))
+			} else {
+				row = append(row, "", "", "", "", "", "", "", "")
+			}
+			if l := r.Load; l != nil {
+				row = append(row, strconv.Itoa(l.Clients), strconv.FormatInt(l.Requests, 10),
+					strconv.FormatInt(l.Errors, 10), strconv.FormatFloat(l.Throughput, 'f', 1, 64))
+			} else {
+				row = append(row, "", "", "", "")
+			}
+			row = append(row, formatGroups(r.Groups, 0))
+			if p := r.Pages; p != nil {
+				row = append(row, strconv.FormatInt(p.Count, 10), formatMs(p.P50), formatMs(p.P99), formatMs(p.Max))
+			} else {
+				row = append(row, "", "", "", "")
+			}
+			if m := r.Memory; m != nil {
+				row = append(row, strconv.FormatUint(m.HeapPeak, 10), strconv.FormatUint(m.Allocated, 10))
+			} else {
+				row = append(row, "", "")
+			}
+			if t := r.Scan; t != nil {
+				row = append(row, strconv.FormatFloat(t.RowsPerSec, 'f', 0, 64), strconv.FormatInt(t.Bytes, 10))
+			} else if in := r.Ingest; in != nil {
+				row = append(row, strconv.FormatFloat(in.RowsPerSec, 'f', 0, 64), "")
+			} else {
+				row = append(row, "", "")
+			}
+			if in := r.Ingest; in != nil {
+				row = append(row, in.Method, strconv.Itoa(in.Workers), strconv.FormatInt(in.Written, 10), strconv.FormatInt(in.Failed, 10),
+					strconv.FormatInt(in.Retried, 10), formatMs(in.Batches.P50), formatMs(in.Batches.P99), formatMs(in.Batches.Max),
+					strconv.FormatInt(in.RequestRetries, 10))
+			} else {
+				row = append(row, "", "", "", "", "", "", "", "", "")
+			}
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		for _, r := range report.Results {
+			if _, err := fmt.Fprintln(w, formatResultLine(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// saveReport 将报告写入 -out-file；未指定文件时，json/csv 格式输出到标准输出
+func saveReport(report Report) {
+	if cfg.OutFile == "" {
+		// table 格式已在测试过程中实时打印
+		if cfg.Output == "table" {
+			return
+		}
+		fmt.Printf("\n>>> 测试报告 (%s):\n", cfg.Output)
+		if err := writeReport(os.Stdout, cfg.Output, report); err != nil {
+			log.Fatalf("Write report failed: %v", err)
+		}
+		return
+	}
+
+	f, err := os.Create(cfg.OutFile)
+	if err != nil {
+		log.Fatalf("Create report file failed: %v", err)
+	}
+	if err := writeReport(f, cfg.Output, report); err != nil {
+		f.Close()
+		log.Fatalf("Write report failed: %v", err)
+	}
+	// 关闭时才落盘的写入错误（如磁盘已满）同样视为写入失败
+	if err := f.Close(); err != nil {
+		log.Fatalf("Write report failed: %v", err)
+	}
+	fmt.Printf("\n>>> 测试报告已写入: %s (%s)\n", cfg.OutFile, cfg.Output)
+}
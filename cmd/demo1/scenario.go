@@ -0,0 +1,238 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- 场景运行环境 ---
+// DB/ES 供 MySQL、ES 专属场景直接使用，对应后端未启用时为 nil
+type Env struct {
+	DB       *sql.DB
+	ES       *elastic.Client
+	Backends []Backend
+}
+
+func newEnv(backends []Backend) *Env {
+	env := &Env{Backends: backends}
+	for _, b := range backends {
+		switch b := b.(type) {
+		case *mysqlBackend:
+			env.DB = b.db
+		case *esBackend:
+			env.ES = b.client
+		}
+	}
+	return env
+}
+
+// Backend 返回已启用的指定后端，未启用时返回 nil
+func (e *Env) Backend(name string) Backend {
+	for _, b := range e.Backends {
+		if b.Name() == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// Scenario 是 Phase 2 中的一个测试场景
+// Run 的 limit 为 0 表示全量
+type Scenario interface {
+	Name() string
+	Description() string
+	Engines() []string
+	Prepare(env *Env) error
+	Run(limit int) Result
+}
+
+// 各场景计算的逻辑问题（Result.Query），相同 Query 的结果会做一致性校验
+const (
+	querySumTopN = "sum_top_n" // 按 id 升序前 N 条的 amount 总和
+)
+
+// levelIndependent 由与数据规模无关的场景实现（如过滤聚合），这类场景只以 limit=0 执行一次
+type levelIndependent interface {
+	LevelIndependent() bool
+}
+
+func isLevelIndependent(s Scenario) bool {
+	li, ok := s.(levelIndependent)
+	return ok && li.LevelIndependent()
+}
+
+// splitLevelIndependent 将场景分为按数据规模执行的场景与只执行一次的场景
+func splitLevelIndependent(scenarios []Scenario) (perLevel, once []Scenario) {
+	for _, s := range scenarios {
+		if isLevelIndependent(s) {
+			once = append(once, s)
+		} else {
+			perLevel = append(perLevel, s)
+		}
+	}
+	return perLevel, once
+}
+
+// skipError 表示场景在当前配置下不适用，Prepare 返回该错误时跳过场景
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string { return e.reason }
+
+func skipScenario(reason string) error {
+	return &skipError{reason: reason}
+}
+
+// 按名称排序保存的场景列表（注册发生在各文件的 init 中，顺序不固定）
+var scenarioRegistry []Scenario
+
+func registerScenario(s Scenario) {
+	if lookupScenario(s.Name()) != nil {
+		panic("duplicate scenario: " + s.Name())
+	}
+	scenarioRegistry = append(scenarioRegistry, s)
+	sort.SliceStable(scenarioRegistry, func(i, j int) bool {
+		return scenarioRegistry[i].Name() < scenarioRegistry[j].Name()
+	})
+}
+
+func lookupScenario(name string) Scenario {
+	for _, s := range scenarioRegistry {
+		if strings.EqualFold(s.Name(), name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// engineEnabled 判断引擎在当前 mode 下是否启用
+func engineEnabled(engine string) bool {
+	for _, b := range cfg.Backends {
+		if b == engine {
+			return true
+		}
+	}
+	return false
+}
+
+func scenarioEnginesEnabled(s Scenario) bool {
+	for _, e := range s.Engines() {
+		if !engineEnabled(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// printScenarios 打印所有已注册的场景（-list-scenarios）
+func printScenarios() {
+	fmt.Println("可用场景:")
+	width := 0
+	for _, s := range scenarioRegistry {
+		if len(s.Name()) > width {
+			width = len(s.Name())
+		}
+	}
+	for _, s := range scenarioRegistry {
+		fmt.Printf("  %-*s [%s] %s\n", width, s.Name(), strings.Join(s.Engines(), ","), s.Description())
+	}
+}
+
+// selectScenarios 根据 -scenarios 与 -mode 选出并准备本次要执行的场景
+// 未指定 -scenarios 时选择所有引擎已启用的场景；Prepare 返回 skipError 的场景会被跳过
+func selectScenarios(env *Env) ([]Scenario, error) {
+	var candidates []Scenario
+	if len(cfg.Scenarios) == 0 {
+		for _, s := range scenarioRegistry {
+			if scenarioEnginesEnabled(s) {
+				candidates = append(candidates, s)
+			}
+		}
+	} else {
+		for _, name := range cfg.Scenarios {
+			s := lookupScenario(name)
+			if s == nil {
+				return nil, fmt.Errorf("unknown scenario %q (use -list-scenarios to list available scenarios)", name)
+			}
+			if !scenarioEnginesEnabled(s) {
+				fmt.Printf(">>> 跳过场景 %s: 引擎 %s 未在 -mode=%s 中启用\n", s.Name(), strings.Join(s.Engines(), ","), cfg.Mode)
+				continue
+			}
+			candidates = append(candidates, s)
+		}
+	}
+
+	var selected []Scenario
+	for _, s := range candidates {
+		if err := s.Prepare(env); err != nil {
+			var skip *skipError
+			if errors.As(err, &skip) {
+				fmt.Printf(">>> 跳过场景 %s: %v\n", s.Name(), err)
+				continue
+			}
+			return nil, fmt.Errorf("prepare scenario %s: %w", s.Name(), err)
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
+
+// groupByEngine 将使用相同引擎的场景分为一组：组内依次执行，组间可并行执行
+func groupByEngine(scenarios []Scenario) [][]Scenario {
+	index := map[string]int{}
+	var groups [][]Scenario
+	for _, s := range scenarios {
+		engines := append([]string(nil), s.Engines()...)
+		sort.Strings(engines)
+		key := strings.Join(engines, "+")
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], s)
+	}
+	return groups
+}
+
+func scenarioNames(scenarios []Scenario) []string {
+	names := make([]string, 0, len(scenarios))
+	for _, s := range scenarios {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// runScenarios 以指定 limit 执行一轮场景，结果写入 collector
+// 常规模式下不同引擎的场景并行执行、同一引擎的场景依次执行；
+// 并发压测模式下所有场景依次执行，避免相互争抢资源
+func runScenarios(scenarios []Scenario, limit int, collector *resultCollector) {
+	var wg sync.WaitGroup
+	for _, group := range groupByEngine(scenarios) {
+		if loadModeEnabled() {
+			for _, sc := range group {
+				collector.add(runLoad(func() Result { return sc.Run(limit) }))
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(group []Scenario) {
+			defer wg.Done()
+			for _, sc := range group {
+				collector.add(runIterations(func() Result {
+					return measureMemory(func() Result { return sc.Run(limit) })
+				}))
+			}
+		}(group)
+	}
+	// 等待本轮所有测试完成再进入下一轮
+	wg.Wait()
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- 场景 B: ES 原生聚合 / RowFetch ---
+type esNativeAggScenario struct {
+	es    *elastic.Client
+	field string // 原生聚合使用的数值字段
+}
+
+// --- 场景 C: ES 脚本聚合 / ScriptFetch ---
+type esScriptAggScenario struct {
+	es *elastic.Client
+}
+
+func init() {
+	registerScenario(&esNativeAggScenario{})
+	registerScenario(&esScriptAggScenario{})
+}
+
+func (s *esNativeAggScenario) Name() string      { return "B" }
+func (s *esNativeAggScenario) Engines() []string { return []string{"es"} }
+func (s *esNativeAggScenario) Description() string {
+	return "ES Sum Aggregation（全量）/ RowFetch 客户端求和（top N by id）"
+}
+
+// Prepare 只有 amount 存在数值字段时才能使用原生 sum 聚合
+func (s *esNativeAggScenario) Prepare(env *Env) error {
+	s.es = env.ES
+	s.field = esAmountNumericField(cfg.ESAmountType)
+	if s.field == "" {
+		return skipScenario(fmt.Sprintf("amount 存储策略为 %s，原生 sum 聚合不可用", cfg.ESAmountType))
+	}
+	return nil
+}
+
+func (s *esNativeAggScenario) Run(limit int) Result {
+	return benchmarkESNativeAgg(s.es, s.field, limit)
+}
+
+func (s *esScriptAggScenario) Name() string      { return "C" }
+func (s *esScriptAggScenario) Engines() []string { return []string{"es"} }
+func (s *esScriptAggScenario) Description() string {
+	return "ES scripted_metric BigDecimal（全量）/ ScriptFetch 客户端求和（top N by id）"
+}
+
+func (s *esScriptAggScenario) Prepare(env *Env) error {
+	s.es = env.ES
+	return nil
+}
+
+func (s *esScriptAggScenario) Run(limit int) Result {
+	return benchmarkESScriptAgg(s.es, limit)
+}
+
+// --- 基准测试: ES 原生聚合 ---
+// benchmarkESNativeAgg: 如果 limit==0 则对数值字段 field 使用 sum 聚合；否则拉取前 limit 条并客户端求和（按 id 升序）
+func benchmarkESNativeAgg(es *elastic.Client, field string, limit int) Result {
+	start := time.Now()
+	ctx := context.Background()
+	res := Result{Scenario: "B", Query: querySumTopN, Engine: "es", Limit: limit, AmountType: cfg.ESAmountType}
+
+	if limit == 0 {
+		// 全量使用聚合
+		res.Type = "Native"
+		sumAgg := elastic.NewSumAggregation().Field(field)
+		sr, err := es.Search().
+			Index("customer_orders").
+			Query(elastic.NewMatchAllQuery()).
+			Size(0). // 不返回 Hits
+			TrackTotalHits(true).
+			Aggregation("total_amount", sumAgg).
+			Do(ctx)
+		res.Duration = time.Since(start)
+
+		if err != nil {
+			res.Error = fmt.Sprintf("ES Native Agg Error: %v", err)
+			return res
+		}
+
+		aggRes, found := sr.Aggregations.Sum("total_amount")
+		if !found || aggRes.Value == nil {
+			res.Error = "ES Native Agg Error: total_amount not found in aggregations"
+			return res
+		}
+		res.Sum = strconv.FormatFloat(*aggRes.Value, 'f', amountScale, 64)
+		res.Rows = sr.TotalHits()
+		res.Note = fmt.Sprintf("(%s, double)", cfg.ESAmountType)
+		return res
+	}
+
+	// 部分数据：排序并拉取前 limit 条，客户端求和以保持与 MySQL 一致
+	res.Type = "RowFetch"
+	if cfg.ESFetch != esFetchSingle {
+		esStreamSum(es, esSourceAmount, limit, &res)
+		return res
+	}
+	sr, err := es.Search().
+		Index("customer_orders").
+		Query(elastic.NewMatchAllQuery()).
+		Sort("id", true).
+		Size(limit).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("amount")).
+		Do(ctx)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("ES Native partial fetch error: %v", err)
+		return res
+	}
+
+	sum := new(big.Rat).SetInt64(0)
+	for _, hit := range sr.Hits.Hits {
+		if rat, ok := sourceAmount(hit.Source); ok {
+			sum.Add(sum, rat)
+		}
+	}
+	res.Duration = time.Since(start)
+	res.Sum = sum.FloatString(amountScale)
+	res.Rows = int64(len(sr.Hits.Hits))
+	res.Note = fmt.Sprintf("(%s, client-side, single)", cfg.ESAmountType)
+	return res
+}
+
+// sourceAmount 从 _source 中解析 amount（数值类型保留原始文本，避免 float64 精度损失）
+func sourceAmount(source json.RawMessage) (*big.Rat, bool) {
+	dec := json.NewDecoder(bytes.NewReader(source))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+	return parseESAmount(doc["amount"], cfg.ESAmountType)
+}
+
+// --- 基准测试: ES 脚本聚合 (使用原生 JSON) ---
+// benchmarkESScriptAgg: 如果 limit==0 使用 scripted_metric（返回字符串），否则客户端拉取前 limit 条并求和
+func benchmarkESScriptAgg(es *elastic.Client, limit int) Result {
+	start := time.Now()
+	ctx := context.Background()
+	res := Result{Scenario: "C", Query: querySumTopN, Engine: "es", Limit: limit, AmountType: cfg.ESAmountType}
+
+	query := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"aggs": map[string]interface{}{
+			"bd_sum": esBigDecimalSumAgg(),
+		},
+	}
+
+	if limit == 0 {
+		// 全量脚本聚合
+		res.Type = "Script"
+		searchResult, err := es.Search().
+			Index("customer_orders").
+			Source(query).
+			Do(ctx)
+		res.Duration = time.Since(start)
+
+		if err != nil {
+			res.Error = fmt.Sprintf("ES Script Agg Error: %v", err)
+			return res
+		}
+
+		sum, err := esScriptedSum(searchResult.Aggregations, "bd_sum")
+		if err != nil {
+			res.Error = fmt.Sprintf("ES Script Agg Error: %v", err)
+			return res
+		}
+
+		res.Sum = sum
+		res.Rows = searchResult.TotalHits()
+		res.Note = fmt.Sprintf("(BigDecimal String, %s)", cfg.ESAmountType)
+		return res
+	}
+
+	// 部分数据：用 script_fields 输出 BigDecimal 字符串，再在客户端高精度求和
+	res.Type = "ScriptFetch"
+	if cfg.ESFetch != esFetchSingle {
+		esStreamSum(es, esScriptAmount, limit, &res)
+		return res
+	}
+	sr, err := es.Search().
+		Index("customer_orders").
+		SearchSource(esScriptAmount.pageSource(limit)).
+		Do(ctx)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("ES Script partial fetch error: %v", err)
+		return res
+	}
+
+	sum := new(big.Rat).SetInt64(0)
+	for _, hit := range sr.Hits.Hits {
+		if rat, ok := scriptFieldAmount(hit); ok {
+			sum.Add(sum, rat)
+		}
+	}
+	res.Duration = time.Since(start)
+	res.Sum = sum.FloatString(amountScale)
+	res.Rows = int64(len(sr.Hits.Hits))
+	res.Note = fmt.Sprintf("(BigDecimal, client-side, single, %s)", cfg.ESAmountType)
+	return res
+}
+
+// ES 部分数据的拉取方式
+const (
+	esFetchSingle      = "single"       // 单个请求 Size(limit)，全部 hits 一次性载入内存
+	esFetchSearchAfter = "search_after" // 按 -page-size 逐页 search_after
+	esFetchScroll      = "scroll"       // 按 -page-size 逐页 scroll
+)
+
+var esFetchModes = []string{esFetchSingle, esFetchSearchAfter, esFetchScroll}
+
+func validESFetch(mode string) bool {
+	for _, m := range esFetchModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// esStreamSum 按 -es-fetch 逐页拉取前 limit 条，每页解析后立即累加到 big.Rat，内存占用只与页大小有关
+func esStreamSum(es *elastic.Client, req esHitRequest, limit int, res *Result) {
+	start := time.Now()
+	var pager pageFetcher
+	if cfg.ESFetch == esFetchScroll {
+		pager = &esScrollPager{es: es, req: req}
+	} else {
+		pager = &esSearchAfterPager{es: es, req: req}
+	}
+	defer pager.close()
+
+	sum, rows, pages, err := paginate(pager, limit, cfg.PageSize)
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("ES %s partial fetch error: %v", res.Type, err)
+		return
+	}
+	res.Sum = sum.FloatString(amountScale)
+	res.Rows = rows
+	res.Pages = &pages
+	res.Note = fmt.Sprintf("(%s, client-side, %s, page=%d)", cfg.ESAmountType, cfg.ESFetch, cfg.PageSize)
+}
+
+// esBigDecimalSumAgg 返回以 BigDecimal 精确求和 amount 的 scripted_metric 聚合定义
+// 使用 BigDecimal 确保精度不丢失（ES7 兼容的 Painless 脚本）
+// 注意：Elasticsearch 中脚本聚合的正确类型是 scripted_metric
+// amount 按存储策略转换为 BigDecimal（keyword 直接读取字符串值）
+func esBigDecimalSumAgg() map[string]interface{} {
+	return map[string]interface{}{
+		"scripted_metric": map[string]interface{}{
+			// 初始化为 BigDecimal，精度9位小数
+			"init_script": "state.total = new java.math.BigDecimal('0').setScale(9, java.math.RoundingMode.HALF_UP)",
+			// 读取 amount，转换为 BigDecimal 并累加
+			"map_script": "state.total = state.total.add(" + esAmountScriptExpr(cfg.ESAmountType) + ".setScale(9, java.math.RoundingMode.HALF_UP))",
+			// 分片内返回字符串形式的 BigDecimal（保持9位精度）
+			"combine_script": "return state.total.setScale(9, java.math.RoundingMode.HALF_UP).toPlainString();",
+			// 跨分片汇总，接收每个分片的字符串表示并用 BigDecimal 累加，最后用 DecimalFormat 返回字符串（保持9位精度）
+			"reduce_script": `
+					java.math.BigDecimal sum = new java.math.BigDecimal('0').setScale(9, java.math.RoundingMode.HALF_UP);
+					for (t in states) {
+						if (t != null) {
+							sum = sum.add(new java.math.BigDecimal(String.valueOf(t)).setScale(9, java.math.RoundingMode.HALF_UP));
+						}
+					}
+					java.text.DecimalFormat df = new java.text.DecimalFormat('0.000000000');
+					return df.format(sum);
+				`,
+		},
+	}
+}
+
+// rawAggregation 将原生 JSON 形式的聚合定义接入 elastic.Aggregation 接口
+type rawAggregation map[string]interface{}
+
+func (a rawAggregation) Source() (interface{}, error) {
+	return map[string]interface{}(a), nil
+}
+
+// esScriptedSum 从聚合结果中取出 scripted_metric 的值（处理多种可能的返回类型）
+func esScriptedSum(aggs elastic.Aggregations, name string) (string, error) {
+	if aggs == nil {
+		return "", fmt.Errorf("no aggregations in response")
+	}
+	rawMsg, found := aggs[name]
+	if !found {
+		return "", fmt.Errorf("%s not found in aggregations", name)
+	}
+	var aggResult map[string]interface{}
+	if err := json.Unmarshal(rawMsg, &aggResult); err != nil {
+		// 打印原始响应以便调试异常情况
+		log.Printf("ES Script Agg: failed to unmarshal aggregation raw json: %v", err)
+		return string(rawMsg), nil
+	}
+	if val, ok := aggResult["value"]; ok {
+		return fmt.Sprint(val), nil
+	}
+	if doc, ok := aggResult[name]; ok {
+		return fmt.Sprint(doc), nil
+	}
+	return fmt.Sprint(aggResult), nil
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- 过滤聚合场景 ---
+// 三类过滤条件在 MySQL 与 ES 上各有一个等价场景，选择度由命令行参数控制：
+//
+//	customer:  指定客户的订单金额总和（-filter-customers）
+//	window:    create_time 时间窗口内的订单金额总和（-filter-window）
+//	amount-gt: 金额大于阈值的订单数（-filter-amount-min）
+const (
+	querySumByCustomer   = "sum_by_customer"
+	querySumByTimeWindow = "sum_by_time_window"
+	queryCountAmountGT   = "count_amount_gt"
+)
+
+type filterKind struct {
+	key   string // 场景名后缀
+	query string
+	desc  string
+	count bool // true 统计条数，false 求和
+}
+
+var filterKinds = []filterKind{
+	{key: "customer", query: querySumByCustomer, desc: "指定客户的订单金额总和"},
+	{key: "window", query: querySumByTimeWindow, desc: "create_time 时间窗口内的订单金额总和"},
+	{key: "amount-gt", query: queryCountAmountGT, desc: "金额大于阈值的订单数", count: true},
+}
+
+// create_time 在 MySQL 与 ES 中的文本格式
+const (
+	createTimeLayout   = "2006-01-02 15:04:05.000000"
+	createTimeESFormat = "yyyy-MM-dd HH:mm:ss.SSSSSS"
+)
+
+type mysqlFilterScenario struct {
+	kind  filterKind
+	db    *sql.DB
+	where string
+	args  []interface{}
+	note  string
+}
+
+type esFilterScenario struct {
+	kind  filterKind
+	es    *elastic.Client
+	query elastic.Query
+	note  string
+}
+
+func init() {
+	for _, k := range filterKinds {
+		registerScenario(&mysqlFilterScenario{kind: k})
+		registerScenario(&esFilterScenario{kind: k})
+	}
+}
+
+// filterCustomerIDs 返回参与过滤的客户 ID（CUST-0 ~ CUST-(n-1)）
+func filterCustomerIDs() []string {
+	ids := make([]string, 0, cfg.FilterCustomers)
+	for i := 0; i < cfg.FilterCustomers; i++ {
+		ids = append(ids, fmt.Sprintf("CUST-%d", i))
+	}
+	return ids
+}
+
+// filterWindow 根据 create_time 的最小/最大值计算时间窗口 [min, min + (max-min)*ratio]
+func filterWindow(minTime, maxTime string) (string, string, error) {
+	lo, err := time.Parse(createTimeLayout, minTime)
+	if err != nil {
+		return "", "", fmt.Errorf("parse min create_time %q: %w", minTime, err)
+	}
+	hi, err := time.Parse(createTimeLayout, maxTime)
+	if err != nil {
+		return "", "", fmt.Errorf("parse max create_time %q: %w", maxTime, err)
+	}
+	span := time.Duration(float64(hi.Sub(lo)) * cfg.FilterWindow).Truncate(time.Microsecond)
+	return lo.Format(createTimeLayout), lo.Add(span).Format(createTimeLayout), nil
+}
+
+func windowNote(start, end string) string {
+	return fmt.Sprintf("(window=[%s, %s])", start, end)
+}
+
+// --- MySQL ---
+
+func (s *mysqlFilterScenario) Name() string           { return "mysql-" + s.kind.key }
+func (s *mysqlFilterScenario) Engines() []string      { return []string{"mysql"} }
+func (s *mysqlFilterScenario) Description() string    { return "MySQL " + s.kind.desc }
+func (s *mysqlFilterScenario) LevelIndependent() bool { return true }
+
+func (s *mysqlFilterScenario) Prepare(env *Env) error {
+	s.db = env.DB
+	switch s.kind.query {
+	case querySumByCustomer:
+		ids := filterCustomerIDs()
+		s.where = "customer_id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		s.args = nil
+		for _, id := range ids {
+			s.args = append(s.args, id)
+		}
+		s.note = fmt.Sprintf("(customers=%d)", len(ids))
+	case querySumByTimeWindow:
+		// DATE_FORMAT 保留微秒，与 ES 端取得的边界完全一致
+		var minTime, maxTime sql.NullString
+		err := s.db.QueryRow(`SELECT DATE_FORMAT(MIN(create_time), '%Y-%m-%d %H:%i:%s.%f'),
+			DATE_FORMAT(MAX(create_time), '%Y-%m-%d %H:%i:%s.%f') FROM customer_orders`).Scan(&minTime, &maxTime)
+		if err != nil {
+			return fmt.Errorf("query create_time range: %w", err)
+		}
+		if !minTime.Valid || !maxTime.Valid {
+			return skipScenario("customer_orders 为空，无法计算时间窗口")
+		}
+		start, end, err := filterWindow(minTime.String, maxTime.String)
+		if err != nil {
+			return err
+		}
+		// 使用 idx_create_time
+		s.where = "create_time BETWEEN ? AND ?"
+		s.args = []interface{}{start, end}
+		s.note = windowNote(start, end)
+	case queryCountAmountGT:
+		// 显式 CAST 为 DECIMAL，避免与字符串参数按 double 比较；使用 idx_amt
+		s.where = "amount > CAST(? AS DECIMAL(19, 9))"
+		s.args = []interface{}{cfg.FilterAmountMin}
+		s.note = fmt.Sprintf("(amount>%s)", cfg.FilterAmountMin)
+	}
+	return nil
+}
+
+func (s *mysqlFilterScenario) Run(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: s.kind.query, Engine: "mysql", Limit: limit, Note: s.note}
+
+	var err error
+	if s.kind.count {
+		res.Type = "FilterCount"
+		err = s.db.QueryRow("SELECT COUNT(*) FROM customer_orders WHERE "+s.where, s.args...).Scan(&res.Rows)
+		res.Sum = strconv.FormatInt(res.Rows, 10)
+	} else {
+		res.Type = "FilterSum"
+		var sumStr sql.NullString
+		err = s.db.QueryRow("SELECT CAST(SUM(amount) AS CHAR), COUNT(*) FROM customer_orders WHERE "+s.where, s.args...).Scan(&sumStr, &res.Rows)
+		res.Sum = "0"
+		if sumStr.Valid {
+			res.Sum = sumStr.String
+		}
+	}
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("MySQL Filter Query Error: %v", err)
+	}
+	return res
+}
+
+// --- ES ---
+
+func (s *esFilterScenario) Name() string           { return "es-" + s.kind.key }
+func (s *esFilterScenario) Engines() []string      { return []string{"es"} }
+func (s *esFilterScenario) Description() string    { return "ES " + s.kind.desc }
+func (s *esFilterScenario) LevelIndependent() bool { return true }
+
+func (s *esFilterScenario) Prepare(env *Env) error {
+	s.es = env.ES
+	switch s.kind.query {
+	case querySumByCustomer:
+		ids := filterCustomerIDs()
+		values := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			values = append(values, id)
+		}
+		s.query = elastic.NewTermsQuery("customer_id", values...)
+		s.note = fmt.Sprintf("(customers=%d, %s)", len(ids), cfg.ESAmountType)
+	case querySumByTimeWindow:
+		minTime, err := esEdgeCreateTime(s.es, true)
+		if err != nil {
+			return err
+		}
+		maxTime, err := esEdgeCreateTime(s.es, false)
+		if err != nil {
+			return err
+		}
+		if minTime == "" || maxTime == "" {
+			return skipScenario("customer_orders 为空，无法计算时间窗口")
+		}
+		start, end, err := filterWindow(minTime, maxTime)
+		if err != nil {
+			return err
+		}
+		s.query = elastic.NewRangeQuery("create_time").Gte(start).Lte(end).Format(createTimeESFormat)
+		s.note = windowNote(start, end)
+	case queryCountAmountGT:
+		q, err := esAmountGreaterThanQuery(cfg.ESAmountType, cfg.FilterAmountMin)
+		if err != nil {
+			return err
+		}
+		s.query = q
+		s.note = fmt.Sprintf("(amount>%s, %s)", cfg.FilterAmountMin, cfg.ESAmountType)
+	}
+	return nil
+}
+
+func (s *esFilterScenario) Run(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: s.kind.query, Engine: "es", Limit: limit, AmountType: cfg.ESAmountType, Note: s.note}
+
+	search := s.es.Search().
+		Index("customer_orders").
+		Query(s.query).
+		Size(0).
+		TrackTotalHits(true)
+	if s.kind.count {
+		res.Type = "FilterCount"
+	} else {
+		res.Type = "FilterSum"
+		search = search.Aggregation("bd_sum", rawAggregation(esBigDecimalSumAgg()))
+	}
+	sr, err := search.Do(context.Background())
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("ES Filter Query Error: %v", err)
+		return res
+	}
+
+	res.Rows = sr.TotalHits()
+	if s.kind.count {
+		res.Sum = strconv.FormatInt(res.Rows, 10)
+		return res
+	}
+	sum, err := esScriptedSum(sr.Aggregations, "bd_sum")
+	if err != nil {
+		res.Error = fmt.Sprintf("ES Filter Query Error: %v", err)
+		return res
+	}
+	// 无匹配文档时 scripted_metric 返回 0.000000000，与 MySQL 的 0 在数值上相等
+	res.Sum = sum
+	return res
+}
+
+// esEdgeCreateTime 取最早（asc=true）或最晚的 create_time 原始字符串，避免 date_nanos 聚合值的精度损失
+func esEdgeCreateTime(es *elastic.Client, asc bool) (string, error) {
+	sr, err := es.Search().
+		Index("customer_orders").
+		Query(elastic.NewMatchAllQuery()).
+		Sort("create_time", asc).
+		Size(1).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("create_time")).
+		Do(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("query create_time range: %w", err)
+	}
+	if len(sr.Hits.Hits) == 0 {
+		return "", nil
+	}
+	var doc struct {
+		CreateTime string `json:"create_time"`
+	}
+	if err := json.Unmarshal(sr.Hits.Hits[0].Source, &doc); err != nil {
+		return "", err
+	}
+	return doc.CreateTime, nil
+}
+
+// plainDecimalPattern 只接受普通十进制写法，big.Rat 支持的分数（1/3）与指数写法
+// 无法被 MySQL CAST(? AS DECIMAL) 与 ES 的 new BigDecimal(String) 一致地解析
+var plainDecimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// parseAmountThreshold 解析 -filter-amount-min，保证各后端收到的是同一个十进制字面量
+func parseAmountThreshold(s string) (*big.Rat, bool) {
+	if !plainDecimalPattern.MatchString(s) {
+		return nil, false
+	}
+	return parseDecimal(s)
+}
+
+// esAmountGreaterThanQuery 构造 amount > threshold 的查询
+// keyword 上的 range 是字典序比较，因此只能用 BigDecimal 脚本查询；数值字段直接使用 range
+func esAmountGreaterThanQuery(t, threshold string) (elastic.Query, error) {
+	rat, ok := parseAmountThreshold(threshold)
+	if !ok {
+		return nil, fmt.Errorf("invalid -filter-amount-min: %q", threshold)
+	}
+	switch t {
+	case amountTypeKeyword:
+		script := elastic.NewScript(esAmountScriptExpr(t)+".compareTo(new java.math.BigDecimal(params.threshold)) > 0").
+			Param("threshold", threshold)
+		return elastic.NewScriptQuery(script), nil
+	case amountTypeLongScaled:
+		return elastic.NewRangeQuery("amount").Gt(scaledThresholdFloor(rat)), nil
+	default:
+		return elastic.NewRangeQuery(esAmountNumericField(t)).Gt(threshold), nil
+	}
+}
+
+// scaledThresholdFloor 将阈值换算为 1e-9 单位并向下取整：存储值是整数，
+// amount > threshold 等价于 units > floor(threshold*1e9)；四舍五入会误排除刚好大于阈值的值
+func scaledThresholdFloor(threshold *big.Rat) string {
+	units := new(big.Rat).Mul(threshold, amountScaleFactor)
+	// 分母恒为正，big.Int 的欧几里得除法即向下取整
+	return new(big.Int).Div(units.Num(), units.Denom()).String()
+}
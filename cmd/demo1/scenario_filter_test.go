@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestParseAmountThreshold(t *testing.T) {
+	for _, s := range []string{"90000", "0", "-1", "123.45", "100.000000001"} {
+		if _, ok := parseAmountThreshold(s); !ok {
+			t.Errorf("parseAmountThreshold(%q) rejected a plain decimal", s)
+		}
+	}
+	for _, s := range []string{"", "1/3", "1e5", "+1", ".5", "1.", "abc"} {
+		if _, ok := parseAmountThreshold(s); ok {
+			t.Errorf("parseAmountThreshold(%q) accepted a non-plain decimal", s)
+		}
+	}
+}
+
+func TestScaledThresholdFloor(t *testing.T) {
+	tests := []struct{ threshold, want string }{
+		{"100", "100000000000"},
+		{"100.000000001", "100000000001"},
+		{"100.0000000005", "100000000000"},
+		{"100.0000000009", "100000000000"},
+		{"-0.0000000005", "-1"},
+	}
+	for _, tt := range tests {
+		rat, ok := parseAmountThreshold(tt.threshold)
+		if !ok {
+			t.Fatalf("parseAmountThreshold(%q) failed", tt.threshold)
+		}
+		if got := scaledThresholdFloor(rat); got != tt.want {
+			t.Errorf("scaledThresholdFloor(%s) = %s, want %s", tt.threshold, got, tt.want)
+		}
+	}
+}
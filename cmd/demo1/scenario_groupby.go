@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- 分组聚合场景: 按客户汇总金额，取总额最高的 K 个客户 ---
+// MySQL: GROUP BY customer_id + ORDER BY SUM(amount) DESC
+// ES:    terms 聚合按近似 sum 排序（多取若干桶），子聚合 scripted_metric 计算精确总额后在客户端重新排序截取
+const queryTopKCustomers = "top_k_customers"
+
+// Group 是分组聚合结果中的一组
+type Group struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type mysqlGroupByScenario struct {
+	db *sql.DB
+}
+
+type esGroupByScenario struct {
+	es *elastic.Client
+}
+
+func init() {
+	registerScenario(&mysqlGroupByScenario{})
+	registerScenario(&esGroupByScenario{})
+}
+
+// sortGroups 按总额降序、客户 ID 升序排序，与 MySQL 的 ORDER BY 保持一致
+func sortGroups(groups []Group, totals map[string]*big.Rat) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if c := totals[groups[i].Key].Cmp(totals[groups[j].Key]); c != 0 {
+			return c > 0
+		}
+		return groups[i].Key < groups[j].Key
+	})
+}
+
+// summarizeGroups 计算各组金额与条数的合计，作为结果的 Sum 与 Rows
+func summarizeGroups(res *Result) {
+	total := new(big.Rat)
+	for _, g := range res.Groups {
+		if v, ok := parseDecimal(g.Value); ok {
+			total.Add(total, v)
+		}
+		res.Rows += g.Count
+	}
+	res.Sum = total.FloatString(amountScale)
+}
+
+// --- MySQL ---
+
+func (s *mysqlGroupByScenario) Name() string           { return "mysql-groupby" }
+func (s *mysqlGroupByScenario) Engines() []string      { return []string{"mysql"} }
+func (s *mysqlGroupByScenario) LevelIndependent() bool { return true }
+func (s *mysqlGroupByScenario) Description() string {
+	return "MySQL GROUP BY customer_id，总额最高的 K 个客户（-groupby-topk）"
+}
+
+func (s *mysqlGroupByScenario) Prepare(env *Env) error {
+	s.db = env.DB
+	return nil
+}
+
+func (s *mysqlGroupByScenario) Run(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: queryTopKCustomers, Engine: "mysql", Type: "GroupBy", Limit: limit,
+		Note: fmt.Sprintf("(top %d)", cfg.GroupByTopK)}
+
+	rows, err := s.db.Query(`
+		SELECT customer_id, CAST(SUM(amount) AS CHAR), COUNT(*) FROM customer_orders
+		GROUP BY customer_id
+		ORDER BY SUM(amount) DESC, customer_id ASC
+		LIMIT ?`, cfg.GroupByTopK)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("MySQL GroupBy Error: %v", err)
+		return res
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.Key, &g.Value, &g.Count); err != nil {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("MySQL GroupBy Scan Error: %v", err)
+			return res
+		}
+		res.Groups = append(res.Groups, g)
+	}
+	res.Duration = time.Since(start)
+	if err := rows.Err(); err != nil {
+		res.Error = fmt.Sprintf("MySQL GroupBy Error: %v", err)
+		return res
+	}
+	summarizeGroups(&res)
+	return res
+}
+
+// --- ES ---
+
+func (s *esGroupByScenario) Name() string           { return "es-groupby" }
+func (s *esGroupByScenario) Engines() []string      { return []string{"es"} }
+func (s *esGroupByScenario) LevelIndependent() bool { return true }
+func (s *esGroupByScenario) Description() string {
+	return "ES terms(customer_id) + scripted_metric，总额最高的 K 个客户（-groupby-topk）"
+}
+
+func (s *esGroupByScenario) Prepare(env *Env) error {
+	s.es = env.ES
+	return nil
+}
+
+// esApproxSumAgg 返回用于 terms 桶排序的近似 sum 聚合（double 精度）
+// scripted_metric 不能作为 terms 的排序依据，因此用它确定候选桶，再用精确总额重新排序
+func esApproxSumAgg() elastic.Aggregation {
+	if field := esAmountNumericField(cfg.ESAmountType); field != "" {
+		return elastic.NewSumAggregation().Field(field)
+	}
+	return elastic.NewSumAggregation().Script(elastic.NewScript("Double.parseDouble(doc['amount'].value)"))
+}
+
+func (s *esGroupByScenario) Run(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: queryTopKCustomers, Engine: "es", Type: "GroupBy", Limit: limit,
+		AmountType: cfg.ESAmountType}
+
+	// 近似排序在边界附近可能与精确排序不同，多取一些候选桶
+	size := cfg.GroupByTopK*2 + 10
+	terms := elastic.NewTermsAggregation().
+		Field("customer_id").
+		Size(size).
+		ShardSize(size*10).
+		OrderByAggregation("approx_sum", false).
+		SubAggregation("approx_sum", esApproxSumAgg()).
+		SubAggregation("bd_sum", rawAggregation(esBigDecimalSumAgg()))
+
+	sr, err := s.es.Search().
+		Index("customer_orders").
+		Query(elastic.NewMatchAllQuery()).
+		Size(0).
+		Aggregation("by_customer", terms).
+		Do(context.Background())
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("ES GroupBy Error: %v", err)
+		return res
+	}
+
+	agg, found := sr.Aggregations.Terms("by_customer")
+	if !found {
+		res.Duration = time.Since(start)
+		res.Error = "ES GroupBy Error: by_customer not found in aggregations"
+		return res
+	}
+	groups := make([]Group, 0, len(agg.Buckets))
+	totals := make(map[string]*big.Rat, len(agg.Buckets))
+	for _, b := range agg.Buckets {
+		value, err := esScriptedSum(b.Aggregations, "bd_sum")
+		if err != nil {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("ES GroupBy Error: %v", err)
+			return res
+		}
+		total, ok := parseDecimal(value)
+		if !ok {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("ES GroupBy Error: invalid decimal %q", value)
+			return res
+		}
+		key := fmt.Sprint(b.Key)
+		totals[key] = total
+		groups = append(groups, Group{Key: key, Value: value, Count: b.DocCount})
+	}
+	sortGroups(groups, totals)
+	if len(groups) > cfg.GroupByTopK {
+		groups = groups[:cfg.GroupByTopK]
+	}
+	res.Duration = time.Since(start)
+	res.Groups = groups
+	res.Note = fmt.Sprintf("(top %d of %d buckets, %s)", cfg.GroupByTopK, len(agg.Buckets), cfg.ESAmountType)
+	summarizeGroups(&res)
+	return res
+}
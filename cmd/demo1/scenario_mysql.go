@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// --- 场景 A: MySQL 服务端 SUM ---
+type mysqlSumScenario struct {
+	db *sql.DB
+}
+
+func init() {
+	registerScenario(&mysqlSumScenario{})
+}
+
+func (s *mysqlSumScenario) Name() string      { return "A" }
+func (s *mysqlSumScenario) Engines() []string { return []string{"mysql"} }
+func (s *mysqlSumScenario) Description() string {
+	return "MySQL SUM（ORDER BY id LIMIT n 子查询，服务端 DECIMAL 求和）"
+}
+
+func (s *mysqlSumScenario) Prepare(env *Env) error {
+	s.db = env.DB
+	return nil
+}
+
+func (s *mysqlSumScenario) Run(limit int) Result {
+	return benchmarkMySQL(s.db, limit)
+}
+
+// --- 基准测试: MySQL ---
+func benchmarkMySQL(db *sql.DB, limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: "A", Query: querySumTopN, Engine: "mysql", Type: "MySQLSum", Limit: limit}
+	var sumStr sql.NullString
+	var err error
+
+	if limit == 0 {
+		// 全量：直接用 MySQL SUM 函数，CAST 为 CHAR 保留精度
+		err = db.QueryRow("SELECT CAST(SUM(amount) AS CHAR), COUNT(*) FROM customer_orders").Scan(&sumStr, &res.Rows)
+	} else {
+		// 部分数据：使用 ORDER BY id 和 LIMIT，然后对结果求和
+		// 标准SQL应该使用子查询来确保先排序和限制，再聚合，以保证逻辑正确性
+		err = db.QueryRow(`
+			SELECT CAST(SUM(amount) AS CHAR), COUNT(*) FROM
+			(SELECT amount FROM customer_orders ORDER BY id ASC LIMIT ?) AS subquery
+		`, limit).Scan(&sumStr, &res.Rows)
+	}
+	res.Duration = time.Since(start)
+
+	if err != nil {
+		res.Error = fmt.Sprintf("MySQL Query Error: %v", err)
+		return res
+	}
+
+	res.Sum = "0"
+	if sumStr.Valid {
+		res.Sum = sumStr.String
+	}
+	return res
+}
+
+// --- MySQL RowScan: 逐行拉取 amount 到应用层求和 ---
+type mysqlRowScanScenario struct {
+	db *sql.DB
+}
+
+// RowScanStats 记录行流式拉取的吞吐与传输量
+type RowScanStats struct {
+	RowsPerSec  float64 `json:"rows_per_sec"`
+	Bytes       int64   `json:"bytes"`
+	BytesSource string  `json:"bytes_source"` // Bytes_sent: 服务端会话统计；payload: 客户端读取的 amount 字节数
+}
+
+func (t RowScanStats) String() string {
+	return fmt.Sprintf("Rows/s=%.0f | Bytes=%s (%s)", t.RowsPerSec, formatBytes(uint64(t.Bytes)), t.BytesSource)
+}
+
+func init() {
+	registerScenario(&mysqlRowScanScenario{})
+}
+
+func (s *mysqlRowScanScenario) Name() string      { return "mysql-rowscan" }
+func (s *mysqlRowScanScenario) Engines() []string { return []string{"mysql"} }
+func (s *mysqlRowScanScenario) Description() string {
+	return "MySQL RowScan（SELECT amount ORDER BY id LIMIT n，rows.Next() 逐行拉取，客户端 big.Rat 求和）"
+}
+
+func (s *mysqlRowScanScenario) Prepare(env *Env) error {
+	s.db = env.DB
+	return nil
+}
+
+// sessionBytesSent 读取当前会话的 Bytes_sent（服务端发送给客户端的字节数）
+func sessionBytesSent(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var name string
+	var value int64
+	err := conn.QueryRowContext(ctx, "SHOW SESSION STATUS LIKE 'Bytes_sent'").Scan(&name, &value)
+	return value, err
+}
+
+func (s *mysqlRowScanScenario) Run(limit int) Result {
+	ctx := context.Background()
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: querySumTopN, Engine: "mysql", Type: "RowScan", Limit: limit}
+
+	// 使用独占连接，保证前后两次读取的 Bytes_sent 属于同一个会话
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("MySQL RowScan Error: %v", err)
+		return res
+	}
+	defer conn.Close()
+	bytesBefore, statusErr := sessionBytesSent(ctx, conn)
+
+	scanStart := time.Now()
+	query := "SELECT amount FROM customer_orders ORDER BY id ASC"
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("MySQL RowScan Error: %v", err)
+		return res
+	}
+	defer rows.Close()
+
+	sum := new(big.Rat)
+	amount := new(big.Rat)
+	var payload int64
+	for rows.Next() {
+		var raw sql.RawBytes
+		if err := rows.Scan(&raw); err != nil {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("MySQL RowScan Scan Error: %v", err)
+			return res
+		}
+		if _, ok := amount.SetString(string(raw)); !ok {
+			res.Duration = time.Since(start)
+			res.Error = fmt.Sprintf("MySQL RowScan Error: invalid decimal %q", raw)
+			return res
+		}
+		sum.Add(sum, amount)
+		payload += int64(len(raw))
+		res.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("MySQL RowScan Error: %v", err)
+		return res
+	}
+	rows.Close()
+	scanTime := time.Since(scanStart)
+	res.Duration = time.Since(start)
+
+	scan := RowScanStats{Bytes: payload, BytesSource: "payload"}
+	if scanTime > 0 {
+		scan.RowsPerSec = float64(res.Rows) / scanTime.Seconds()
+	}
+	// 无权限读取会话状态时退回到客户端统计的 amount 字节数（不含协议开销）
+	if statusErr == nil {
+		if bytesAfter, err := sessionBytesSent(ctx, conn); err == nil {
+			scan.Bytes = bytesAfter - bytesBefore
+			scan.BytesSource = "Bytes_sent"
+		}
+	}
+	res.Sum = sum.FloatString(amountScale)
+	res.Scan = &scan
+	res.Note = "(client-side)"
+	return res
+}
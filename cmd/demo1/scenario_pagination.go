@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- 深分页场景: 按 id 升序逐页拉取前 limit 行（limit=0 表示拉取到末尾），客户端求和 ---
+// MySQL: keyset (WHERE id > ?) / LIMIT ... OFFSET
+// ES:    from/size / search_after / scroll / point-in-time + search_after
+// 结果的 Query 与场景 A 相同，可直接与服务端 SUM 做一致性校验
+const esPageKeepAlive = "1m"
+
+// pageFetcher 拉取下一页（最多 size 行）的 amount，返回空切片表示已无数据
+type pageFetcher interface {
+	fetch(size int) ([]*big.Rat, error)
+	close()
+}
+
+type paginationKind struct {
+	key    string // 场景名后缀
+	engine string
+	typ    string
+	desc   string
+	open   func(env *Env) (pageFetcher, error)
+}
+
+var paginationKinds = []paginationKind{
+	{key: "keyset", engine: "mysql", typ: "Keyset", desc: "MySQL keyset 分页（WHERE id > ? ORDER BY id LIMIT n）",
+		open: func(env *Env) (pageFetcher, error) { return &mysqlKeysetPager{db: env.DB}, nil }},
+	{key: "offset", engine: "mysql", typ: "Offset", desc: "MySQL OFFSET 分页（ORDER BY id LIMIT n OFFSET m）",
+		open: func(env *Env) (pageFetcher, error) { return &mysqlOffsetPager{db: env.DB}, nil }},
+	{key: "from", engine: "es", typ: "FromSize", desc: "ES from/size 分页（sort id）",
+		open: func(env *Env) (pageFetcher, error) { return &esFromSizePager{es: env.ES, req: esSourceAmount}, nil }},
+	{key: "search-after", engine: "es", typ: "SearchAfter", desc: "ES search_after 分页（sort id）",
+		open: func(env *Env) (pageFetcher, error) { return &esSearchAfterPager{es: env.ES, req: esSourceAmount}, nil }},
+	{key: "scroll", engine: "es", typ: "Scroll", desc: "ES scroll 分页（sort id）",
+		open: func(env *Env) (pageFetcher, error) { return &esScrollPager{es: env.ES, req: esSourceAmount}, nil }},
+	{key: "pit", engine: "es", typ: "PIT", desc: "ES point-in-time + search_after 分页（sort id）",
+		open: func(env *Env) (pageFetcher, error) { return openESPITPager(env.ES, esSourceAmount) }},
+}
+
+type paginationScenario struct {
+	kind paginationKind
+	env  *Env
+}
+
+func init() {
+	for _, k := range paginationKinds {
+		registerScenario(&paginationScenario{kind: k})
+	}
+}
+
+func (s *paginationScenario) Name() string      { return s.kind.engine + "-page-" + s.kind.key }
+func (s *paginationScenario) Engines() []string { return []string{s.kind.engine} }
+func (s *paginationScenario) Description() string {
+	return s.kind.desc + "，每页 -page-size 行"
+}
+
+func (s *paginationScenario) Prepare(env *Env) error {
+	s.env = env
+	return nil
+}
+
+func (s *paginationScenario) Run(limit int) Result {
+	start := time.Now()
+	res := Result{Scenario: s.Name(), Query: querySumTopN, Engine: s.kind.engine, Type: s.kind.typ, Limit: limit}
+	if s.kind.engine == "es" {
+		res.AmountType = cfg.ESAmountType
+	}
+
+	// 打开游标（PIT）的耗时计入总耗时
+	pager, err := s.kind.open(s.env)
+	if err != nil {
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("%s Pagination Error: %v", engineLabel(s.kind.engine), err)
+		return res
+	}
+	defer pager.close()
+
+	sum, rows, pages, err := paginate(pager, limit, cfg.PageSize)
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("%s Pagination Error: %v", engineLabel(s.kind.engine), err)
+		return res
+	}
+	res.Sum = sum.FloatString(amountScale)
+	res.Rows = rows
+	res.Pages = &pages
+	res.Note = fmt.Sprintf("(page=%d, pages=%d)", cfg.PageSize, pages.Count)
+	return res
+}
+
+// paginate 逐页拉取直到取满 limit 行或数据耗尽，返回金额总和、行数与每页耗时分布
+func paginate(pager pageFetcher, limit, pageSize int) (*big.Rat, int64, LatencyStats, error) {
+	sum := new(big.Rat)
+	h := newLatencyHistogram()
+	var rows int64
+	for limit == 0 || rows < int64(limit) {
+		size := pageSize
+		if limit > 0 && int64(limit)-rows < int64(size) {
+			size = int(int64(limit) - rows)
+		}
+		t := time.Now()
+		amounts, err := pager.fetch(size)
+		if err != nil {
+			return nil, rows, latencyStatsFrom(h), err
+		}
+		recordLatency(h, time.Since(t))
+		for _, a := range amounts {
+			sum.Add(sum, a)
+		}
+		rows += int64(len(amounts))
+		if len(amounts) < size {
+			break
+		}
+	}
+	return sum, rows, latencyStatsFrom(h), nil
+}
+
+// --- MySQL ---
+
+type mysqlKeysetPager struct {
+	db     *sql.DB
+	lastID int64
+}
+
+func (p *mysqlKeysetPager) fetch(size int) ([]*big.Rat, error) {
+	rows, err := p.db.Query("SELECT id, amount FROM customer_orders WHERE id > ? ORDER BY id LIMIT ?", p.lastID, size)
+	if err != nil {
+		return nil, err
+	}
+	return scanPageAmounts(rows, &p.lastID)
+}
+
+func (p *mysqlKeysetPager) close() {}
+
+type mysqlOffsetPager struct {
+	db     *sql.DB
+	offset int
+}
+
+func (p *mysqlOffsetPager) fetch(size int) ([]*big.Rat, error) {
+	rows, err := p.db.Query("SELECT id, amount FROM customer_orders ORDER BY id LIMIT ? OFFSET ?", size, p.offset)
+	if err != nil {
+		return nil, err
+	}
+	var lastID int64
+	amounts, err := scanPageAmounts(rows, &lastID)
+	p.offset += len(amounts)
+	return amounts, err
+}
+
+func (p *mysqlOffsetPager) close() {}
+
+// scanPageAmounts 读取 (id, amount) 结果集，并记录最后一行的 id
+func scanPageAmounts(rows *sql.Rows, lastID *int64) ([]*big.Rat, error) {
+	defer rows.Close()
+	var amounts []*big.Rat
+	for rows.Next() {
+		var amount string
+		if err := rows.Scan(lastID, &amount); err != nil {
+			return nil, err
+		}
+		rat, ok := parseDecimal(amount)
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal %q", amount)
+		}
+		amounts = append(amounts, rat)
+	}
+	return amounts, rows.Err()
+}
+
+// --- ES ---
+
+// esHitRequest 描述每页 hit 返回 amount 的方式（_source 或 script_fields）及其解析方法
+type esHitRequest struct {
+	source func() *elastic.SearchSource
+	amount func(hit *elastic.SearchHit) (*big.Rat, bool)
+}
+
+// esSourceAmount 从 _source 读取 amount
+var esSourceAmount = esHitRequest{
+	source: func() *elastic.SearchSource {
+		return elastic.NewSearchSource().FetchSourceContext(elastic.NewFetchSourceContext(true).Include("amount"))
+	},
+	amount: func(hit *elastic.SearchHit) (*big.Rat, bool) { return sourceAmount(hit.Source) },
+}
+
+// esScriptAmount 由 script_fields 将 amount 转换为 BigDecimal 字符串输出
+var esScriptAmount = esHitRequest{
+	source: func() *elastic.SearchSource {
+		// 按存储策略转换为 BigDecimal 后输出字符串
+		script := elastic.NewScript("return " + esAmountScriptExpr(cfg.ESAmountType) + ".setScale(9, java.math.RoundingMode.HALF_UP).toPlainString()")
+		return elastic.NewSearchSource().FetchSource(false).ScriptFields(elastic.NewScriptField("bd_amount", script))
+	},
+	amount: scriptFieldAmount,
+}
+
+// scriptFieldAmount 解析 script_fields 中的 bd_amount
+func scriptFieldAmount(hit *elastic.SearchHit) (*big.Rat, bool) {
+	values, ok := hit.Fields["bd_amount"].([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+	strVal, ok := values[0].(string)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strVal)
+}
+
+// pageSource 构造按 id 升序取 size 行的请求体
+func (r esHitRequest) pageSource(size int) *elastic.SearchSource {
+	return r.source().Query(elastic.NewMatchAllQuery()).Sort("id", true).Size(size)
+}
+
+// hitAmounts 解析一页 hits 的 amount（最多取 size 个）
+func (r esHitRequest) hitAmounts(hits []*elastic.SearchHit, size int) ([]*big.Rat, error) {
+	if len(hits) > size {
+		hits = hits[:size]
+	}
+	amounts := make([]*big.Rat, 0, len(hits))
+	for _, hit := range hits {
+		rat, ok := r.amount(hit)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount in document %s", hit.Id)
+		}
+		amounts = append(amounts, rat)
+	}
+	return amounts, nil
+}
+
+type esFromSizePager struct {
+	es   *elastic.Client
+	req  esHitRequest
+	from int
+}
+
+// from+size 受 index.max_result_window 限制（初始化时设置为 10,000,000）
+func (p *esFromSizePager) fetch(size int) ([]*big.Rat, error) {
+	sr, err := p.es.Search().
+		Index("customer_orders").
+		SearchSource(p.req.pageSource(size).From(p.from)).
+		Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	amounts, err := p.req.hitAmounts(sr.Hits.Hits, size)
+	p.from += len(amounts)
+	return amounts, err
+}
+
+func (p *esFromSizePager) close() {}
+
+type esSearchAfterPager struct {
+	es        *elastic.Client
+	req       esHitRequest
+	pit       *elastic.PointInTime // 非空时在 PIT 上分页
+	sortAfter []interface{}
+}
+
+func (p *esSearchAfterPager) fetch(size int) ([]*big.Rat, error) {
+	source := p.req.pageSource(size)
+	if p.sortAfter != nil {
+		source = source.SearchAfter(p.sortAfter...)
+	}
+	search := p.es.Search()
+	if p.pit != nil {
+		// PIT 请求不能指定索引，ES 会自动追加 _shard_doc 作为排序的 tiebreaker
+		source = source.PointInTime(p.pit)
+	} else {
+		search = search.Index("customer_orders")
+	}
+	sr, err := search.SearchSource(source).Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	hits := sr.Hits.Hits
+	if len(hits) > 0 {
+		p.sortAfter = hits[len(hits)-1].Sort
+	}
+	if p.pit != nil && sr.PitId != "" {
+		p.pit = elastic.NewPointInTimeWithKeepAlive(sr.PitId, esPageKeepAlive)
+	}
+	return p.req.hitAmounts(hits, size)
+}
+
+func (p *esSearchAfterPager) close() {
+	if p.pit != nil {
+		p.es.ClosePointInTime(p.pit.Id).Do(context.Background())
+	}
+}
+
+func openESPITPager(es *elastic.Client, req esHitRequest) (pageFetcher, error) {
+	pit, err := es.OpenPointInTime("customer_orders").KeepAlive(esPageKeepAlive).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("open point in time: %w", err)
+	}
+	return &esSearchAfterPager{es: es, req: req, pit: elastic.NewPointInTimeWithKeepAlive(pit.Id, esPageKeepAlive)}, nil
+}
+
+// scroll 的页大小在首个请求时确定，最后一页多取的行在客户端丢弃
+type esScrollPager struct {
+	es     *elastic.Client
+	req    esHitRequest
+	scroll *elastic.ScrollService
+}
+
+func (p *esScrollPager) fetch(size int) ([]*big.Rat, error) {
+	if p.scroll == nil {
+		p.scroll = p.es.Scroll("customer_orders").
+			SearchSource(p.req.pageSource(cfg.PageSize)).
+			KeepAlive(esPageKeepAlive)
+	}
+	sr, err := p.scroll.Do(context.Background())
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.req.hitAmounts(sr.Hits.Hits, size)
+}
+
+func (p *esScrollPager) close() {
+	if p.scroll != nil {
+		p.scroll.Clear(context.Background())
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// --- 延迟统计 ---
+type LatencyStats struct {
+	Count  int64         `json:"count"`
+	Min    time.Duration `json:"min_ns"`
+	Max    time.Duration `json:"max_ns"`
+	Mean   time.Duration `json:"mean_ns"`
+	P50    time.Duration `json:"p50_ns"`
+	P95    time.Duration `json:"p95_ns"`
+	P99    time.Duration `json:"p99_ns"`
+	StdDev time.Duration `json:"stddev_ns"`
+}
+
+// HDR 直方图以微秒记录，范围 1µs ~ 1h，3 位有效数字
+const (
+	histogramMinValue = 1
+	histogramMaxValue = int64(time.Hour / time.Microsecond)
+	histogramSigFigs  = 3
+)
+
+func newLatencyHistogram() *hdrhistogram.Histogram {
+	return hdrhistogram.New(histogramMinValue, histogramMaxValue, histogramSigFigs)
+}
+
+// recordLatency 记录一次耗时，超出范围的值截断到边界
+func recordLatency(h *hdrhistogram.Histogram, d time.Duration) {
+	us := int64(d / time.Microsecond)
+	if us < histogramMinValue {
+		us = histogramMinValue
+	}
+	if us > histogramMaxValue {
+		us = histogramMaxValue
+	}
+	h.RecordValue(us)
+}
+
+func latencyStatsFrom(h *hdrhistogram.Histogram) LatencyStats {
+	us := func(v float64) time.Duration { return time.Duration(v * float64(time.Microsecond)) }
+	return LatencyStats{
+		Count:  h.TotalCount(),
+		Min:    us(float64(h.Min())),
+		Max:    us(float64(h.Max())),
+		Mean:   us(h.Mean()),
+		P50:    us(float64(h.ValueAtQuantile(50))),
+		P95:    us(float64(h.ValueAtQuantile(95))),
+		P99:    us(float64(h.ValueAtQuantile(99))),
+		StdDev: us(h.StdDev()),
+	}
+}
+
+func (s LatencyStats) String() string {
+	return fmt.Sprintf("N=%d | Min=%v | P50=%v | P95=%v | P99=%v | Max=%v | Mean=%v | StdDev=%v",
+		s.Count, s.Min, s.P50, s.P95, s.P99, s.Max, s.Mean, s.StdDev)
+}
+
+// runIterations 先执行 warmup 次预热（结果丢弃），再执行 iterations 次并统计延迟分布
+// 任意一次执行出错即停止并返回该错误结果
+func runIterations(run func() Result) Result {
+	for i := 0; i < cfg.Warmup; i++ {
+		if res := run(); res.Error != "" {
+			return res
+		}
+	}
+
+	if cfg.Iterations <= 1 {
+		return run()
+	}
+
+	h := newLatencyHistogram()
+	var last Result
+	for i := 0; i < cfg.Iterations; i++ {
+		last = run()
+		if last.Error != "" {
+			return last
+		}
+		recordLatency(h, last.Duration)
+	}
+
+	stats := latencyStatsFrom(h)
+	last.Duration = stats.Mean
+	last.Stats = &stats
+	return last
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// --- Sum 一致性校验 ---
+type ConsistencyCheck struct {
+	Query       string `json:"query"`
+	Limit       int    `json:"limit"`
+	Baseline    string `json:"baseline"` // 形如 mysql/A
+	BaselineSum string `json:"baseline_sum"`
+	Target      string `json:"target"`
+	TargetSum   string `json:"target_sum"`
+	Delta       string `json:"delta"` // Target - Baseline
+	Match       bool   `json:"match"`
+	Detail      string `json:"detail,omitempty"` // 分组结果不一致时的首个差异
+	Error       string `json:"error,omitempty"`
+}
+
+func resultKey(r Result) string {
+	return r.Engine + "/" + r.Scenario
+}
+
+// parseDecimal 将 Sum 字符串解析为 big.Rat，保证比较时不丢失精度
+func parseDecimal(s string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(s)
+}
+
+// verifySums 对同一数据规模下的所有成功结果按 Query 分组做精确比对
+// ref 可用时，sum_top_n 的所有结果都与参考引擎的期望值比对（含行数）
+// 失败的结果、以及 expected 中有但本轮没有结果的引擎都记为无法比较的校验项
+func verifySums(limit int, results []Result, expected map[string][]string, ref *referenceStore) []ConsistencyCheck {
+	groups := map[string][]Result{}
+	present := map[string]map[string]bool{} // Query -> 本轮有结果（含失败）的引擎
+	var queries []string
+	var checks []ConsistencyCheck
+	for _, r := range results {
+		if r.Limit != limit {
+			continue
+		}
+		if _, ok := present[r.Query]; !ok {
+			queries = append(queries, r.Query)
+			present[r.Query] = map[string]bool{}
+		}
+		present[r.Query][r.Engine] = true
+		if r.Error != "" {
+			checks = append(checks, failedCheck(r.Query, limit, resultKey(r), r.Error))
+			continue
+		}
+		groups[r.Query] = append(groups[r.Query], r)
+	}
+
+	for _, q := range queries {
+		for _, engine := range expected[q] {
+			if !present[q][engine] {
+				checks = append(checks, failedCheck(q, limit, engine, "no result from this engine"))
+			}
+		}
+		if q == querySumTopN && ref.available() == nil {
+			checks = append(checks, verifyAgainst(ref.result(limit), groups[q])...)
+			continue
+		}
+		checks = append(checks, verifyGroup(q, limit, groups[q])...)
+	}
+	return checks
+}
+
+// failedCheck 返回一个无法比较的校验项，用于失败或缺失的结果
+func failedCheck(query string, limit int, target, reason string) ConsistencyCheck {
+	return ConsistencyCheck{Query: query, Limit: limit, Target: target, Error: reason}
+}
+
+// resultEngines 按 Query 汇总结果（含失败）中出现过的引擎，作为后续各轮校验应有的引擎
+func resultEngines(results []Result) map[string][]string {
+	engines := map[string][]string{}
+	seen := map[string]bool{}
+	for _, r := range results {
+		if key := r.Query + "\x00" + r.Engine; !seen[key] {
+			seen[key] = true
+			engines[r.Query] = append(engines[r.Query], r.Engine)
+		}
+	}
+	return engines
+}
+
+// verifyGroup 以 MySQL 结果为基准（DECIMAL 精确求和），没有 MySQL 时以第一个结果为基准
+func verifyGroup(query string, limit int, candidates []Result) []ConsistencyCheck {
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	baseIdx := 0
+	for i, r := range candidates {
+		if r.Engine == "mysql" {
+			baseIdx = i
+			break
+		}
+	}
+	base := candidates[baseIdx]
+	targets := make([]Result, 0, len(candidates)-1)
+	targets = append(targets, candidates[:baseIdx]...)
+	targets = append(targets, candidates[baseIdx+1:]...)
+	return verifyAgainst(base, targets)
+}
+
+// verifyAgainst 将每个结果与基准结果比较；基准来自参考引擎时同时比较行数
+func verifyAgainst(base Result, targets []Result) []ConsistencyCheck {
+	baseSum, baseOK := parseDecimal(base.Sum)
+
+	var checks []ConsistencyCheck
+	for _, r := range targets {
+		check := ConsistencyCheck{
+			Query:       base.Query,
+			Limit:       base.Limit,
+			Baseline:    resultKey(base),
+			BaselineSum: base.Sum,
+			Target:      resultKey(r),
+			TargetSum:   r.Sum,
+		}
+		targetSum, targetOK := parseDecimal(r.Sum)
+		switch {
+		case !baseOK:
+			check.Error = fmt.Sprintf("invalid decimal %q from %s", base.Sum, check.Baseline)
+		case !targetOK:
+			check.Error = fmt.Sprintf("invalid decimal %q from %s", r.Sum, check.Target)
+		default:
+			delta := new(big.Rat).Sub(targetSum, baseSum)
+			check.Delta = delta.FloatString(9)
+			check.Match = delta.Sign() == 0
+			// 分组结果需要逐组比对（顺序、键与精确金额均一致）
+			if base.Groups != nil || r.Groups != nil {
+				if detail := diffGroups(base.Groups, r.Groups); detail != "" {
+					check.Match = false
+					check.Detail = detail
+				}
+			}
+			if base.Engine == referenceEngine && check.Match && r.Rows != base.Rows {
+				check.Match = false
+				check.Detail = fmt.Sprintf("行数不同: %d vs %d", base.Rows, r.Rows)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func formatCheckLine(c ConsistencyCheck) string {
+	prefix := fmt.Sprintf("[校验  ] Query=%s | Limit=%-8s | %s vs %s", c.Query, limitLabel(c.Limit), c.Baseline, c.Target)
+	switch {
+	case c.Baseline == "":
+		// 失败或缺失的结果没有参与比对
+		return fmt.Sprintf("[校验  ] Query=%s | Limit=%-8s | %s | 结果缺失: %s", c.Query, limitLabel(c.Limit), c.Target, c.Error)
+	case c.Error != "":
+		return fmt.Sprintf("%s | 无法比较: %s", prefix, c.Error)
+	case c.Match:
+		return fmt.Sprintf("%s | 一致 | Sum=%s", prefix, c.BaselineSum)
+	case c.Detail != "":
+		return fmt.Sprintf("%s | 不一致 | %s", prefix, c.Detail)
+	default:
+		return fmt.Sprintf("%s | 不一致 | %s vs %s | Delta=%s", prefix, c.BaselineSum, c.TargetSum, c.Delta)
+	}
+}
+
+// diffGroups 逐组比较两个有序分组结果，返回首个差异的描述，完全一致时返回空
+func diffGroups(base, target []Group) string {
+	if len(base) != len(target) {
+		return fmt.Sprintf("组数不同: %d vs %d", len(base), len(target))
+	}
+	for i := range base {
+		b, t := base[i], target[i]
+		if b.Key != t.Key {
+			return fmt.Sprintf("第 %d 组键不同: %s vs %s", i+1, b.Key, t.Key)
+		}
+		bv, bok := parseDecimal(b.Value)
+		tv, tok := parseDecimal(t.Value)
+		if !bok || !tok || bv.Cmp(tv) != 0 {
+			return fmt.Sprintf("第 %d 组 %s 金额不同: %s vs %s", i+1, b.Key, b.Value, t.Value)
+		}
+	}
+	return ""
+}
+
+// countMismatches 统计不一致（含无法比较）的校验项数量
+func countMismatches(checks []ConsistencyCheck) int {
+	n := 0
+	for _, c := range checks {
+		if !c.Match {
+			n++
+		}
+	}
+	return n
+}
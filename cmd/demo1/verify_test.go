@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestVerifySumsFlagsFailedAndMissingResults(t *testing.T) {
+	expected := map[string][]string{querySumTopN: {"mysql", "es", "pg"}}
+	results := []Result{
+		{Scenario: "A", Query: querySumTopN, Engine: "mysql", Limit: 10, Sum: "1.5"},
+		{Scenario: "B", Query: querySumTopN, Engine: "es", Limit: 10, Error: "timeout"},
+	}
+	checks := verifySums(10, results, expected, nil)
+
+	failed := map[string]bool{}
+	for _, c := range checks {
+		if c.Match {
+			t.Errorf("unexpected matching check %+v", c)
+			continue
+		}
+		failed[c.Target] = c.Error != ""
+	}
+	for _, target := range []string{"es/B", "pg"} {
+		if !failed[target] {
+			t.Errorf("no failed check with error for %s, got %+v", target, checks)
+		}
+	}
+	if n := countMismatches(checks); n != 2 {
+		t.Errorf("countMismatches = %d, want 2", n)
+	}
+}
@@ -2,18 +2,22 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"database/sql"
 	"flag"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"log"
 	"math/rand"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // 配置
@@ -28,8 +32,34 @@ var (
 	tablePrefix     = "bench_table_" // 表名前缀
 	forceLoad       = false          // 强制重新导入数据
 	largeTableIndex = false          // 大表是否创建pkb唯一索引
+	seed            int64            // 数据生成的随机种子（0 表示使用当前时间）
+	loadMethod      = methodMulti    // 写入方式
 )
 
+// 写入方式（-mysql-load-method）
+// multi:    每个批次拼接一条多行 INSERT（默认），驱动每次执行都会重新 prepare
+// prepared: 每张表按批次行数 prepare 一次多行 INSERT，后续批次复用
+// tx:       每个批次开启一个事务，逐行执行预编译的单行 INSERT 后提交
+// loaddata: 每个批次通过驱动的 Reader 处理器以 LOAD DATA LOCAL INFILE 发送（需服务端开启 local_infile）
+const (
+	methodMulti    = "multi"
+	methodPrepared = "prepared"
+	methodTx       = "tx"
+	methodLoadData = "loaddata"
+)
+
+var loadMethods = []string{methodMulti, methodPrepared, methodTx, methodLoadData}
+
+// 表的列（与 INSERT 语句的列顺序一致），LOAD DATA 使用
+const benchColumns = "pkb, col_varchar_1, col_varchar_2, col_varchar_3, col_int_1, col_int_2, col_int_3, " +
+	"col_bigint_1, col_bigint_2, col_decimal_1, col_decimal_2, col_float_1, col_double_1, " +
+	"col_datetime_1, col_datetime_2, col_date_1, col_text_1, col_tinyint_1, col_smallint_1"
+
+const benchColumnCount = 19
+
+// 生成数据的基准时间（固定值，保证相同种子生成相同数据）
+var baseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func init() {
 	flag.StringVar(&mysqlDSN, "mysql", mysqlDSN, "MySQL连接串")
 	flag.IntVar(&totalTables, "tables", totalTables, "总表数量")
@@ -40,11 +70,18 @@ func init() {
 	flag.IntVar(&concurrency, "concurrency", concurrency, "并发数")
 	flag.BoolVar(&forceLoad, "force", forceLoad, "强制重新导入数据")
 	flag.BoolVar(&largeTableIndex, "large-index", largeTableIndex, "大表是否创建pkb唯一索引")
+	flag.Int64Var(&seed, "seed", seed, "数据生成的随机种子（0 表示使用当前时间，生成数据时会打印实际使用的种子）")
+	flag.StringVar(&loadMethod, "mysql-load-method", loadMethod, "写入方式: multi(默认，多行 INSERT), prepared (复用预编译的多行 INSERT), tx (事务内逐行 INSERT), loaddata (LOAD DATA LOCAL INFILE)")
 	flag.Parse()
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	if !validLoadMethod(loadMethod) {
+		log.Fatalf("Invalid -mysql-load-method: %s (supported: %s)", loadMethod, strings.Join(loadMethods, ", "))
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
 
 	// 如果命令行没有指定MySQL连接串，则交互式输入
 	reader := bufio.NewReader(os.Stdin)
@@ -90,7 +127,9 @@ func main() {
 
 	// Phase 2: 预置数据
 	fmt.Println("\n========== Phase 2: 预置数据 ==========")
-	loadData(db)
+	if err := loadData(db); err != nil {
+		log.Fatalf("Phase 2 incomplete: %v", err)
+	}
 
 	// 等待用户输入
 	fmt.Println("\n========================================")
@@ -229,8 +268,17 @@ func createTables(db *sql.DB) {
 	}
 }
 
-// loadData 加载数据
-func loadData(db *sql.DB) {
+// loadCounters 记录数据导入的进度与结果，多个工作协程按批次并发累加
+type loadCounters struct {
+	completedTables int64
+	completedRows   int64 // 已处理的行数（含跳过的表与失败的批次），用于计算进度
+	insertedRows    int64 // 本次实际写入的行数（不含跳过的表与失败的行），用于计算吞吐
+	failedRows      int64
+	failedBatches   int64
+}
+
+// loadData 加载数据，任一批次写入失败时返回错误
+func loadData(db *sql.DB) error {
 	start := time.Now()
 
 	// 检查是否需要跳过数据导入
@@ -249,12 +297,14 @@ func loadData(db *sql.DB) {
 		}
 		if skipCount == totalTables {
 			fmt.Printf(">>> 所有 %d 张表数据已满足要求，跳过数据导入 (使用 -force 强制重新导入)\n", totalTables)
-			return
+			return nil
 		}
 		if skipCount > 0 {
 			fmt.Printf(">>> %d 张表数据已存在，将跳过\n", skipCount)
 		}
 	}
+	// 至少有一张表会重新生成数据，此时打印的种子才能复现本次数据
+	fmt.Printf(">>> 随机种子: %d（使用 -seed %d 可复现本次生成的数据）\n", seed, seed)
 
 	// 使用工作池模式并发加载
 	type tableTask struct {
@@ -265,9 +315,7 @@ func loadData(db *sql.DB) {
 
 	tasks := make(chan tableTask, totalTables)
 	var wg sync.WaitGroup
-	var completedTables int64
-	var completedRows int64
-	var skippedTables int64
+	var c loadCounters
 
 	// 启动工作协程
 	for i := 0; i < concurrency; i++ {
@@ -277,14 +325,12 @@ func loadData(db *sql.DB) {
 			for task := range tasks {
 				// 非强制模式下检查是否需要跳过
 				if !forceLoad && checkTableData(db, task.tableName, task.rows) {
-					atomic.AddInt64(&skippedTables, 1)
-					atomic.AddInt64(&completedTables, 1)
-					atomic.AddInt64(&completedRows, int64(task.rows))
+					atomic.AddInt64(&c.completedTables, 1)
+					atomic.AddInt64(&c.completedRows, int64(task.rows))
 					continue
 				}
-				loadTableData(db, task.tableName, task.rows, task.isLarge)
-				atomic.AddInt64(&completedTables, 1)
-				atomic.AddInt64(&completedRows, int64(task.rows))
+				loadTableData(db, &c, task.tableName, task.rows, task.isLarge)
+				atomic.AddInt64(&c.completedTables, 1)
 			}
 		}()
 	}
@@ -298,10 +344,15 @@ func loadData(db *sql.DB) {
 		for {
 			select {
 			case <-ticker.C:
-				tables := atomic.LoadInt64(&completedTables)
-				rows := atomic.LoadInt64(&completedRows)
-				fmt.Printf(">>> 进度: %d/%d 表, %d/%d 行 (%.1f%%)\n",
-					tables, totalTables, rows, totalRows, float64(rows)/float64(totalRows)*100)
+				tables := atomic.LoadInt64(&c.completedTables)
+				rows := atomic.LoadInt64(&c.completedRows)
+				fmt.Printf(">>> 进度: %d/%d 表, %d/%d 行 (%.1f%%), %.0f 行/s",
+					tables, totalTables, rows, totalRows, float64(rows)/float64(totalRows)*100,
+					float64(atomic.LoadInt64(&c.insertedRows))/time.Since(start).Seconds())
+				if failed := atomic.LoadInt64(&c.failedRows); failed > 0 {
+					fmt.Printf(", 失败 %d 行", failed)
+				}
+				fmt.Println()
 			case <-done:
 				return
 			}
@@ -324,11 +375,19 @@ func loadData(db *sql.DB) {
 	close(done)
 
 	totalRows := int64(largeTables*largeTableRows + (totalTables-largeTables)*smallTableRows)
-	fmt.Printf("\n>>> 数据加载完成: %d 表, %d 行, 总耗时: %v\n", totalTables, totalRows, time.Since(start))
+	elapsed := time.Since(start)
+	fmt.Printf("\n>>> 数据加载完成: %d 表, %d 行, 总耗时: %v\n", totalTables, totalRows, elapsed)
+	fmt.Printf(">>> 写入方式: %s, 本次写入 %d 行, 吞吐: %.0f 行/s\n",
+		loadMethod, c.insertedRows, float64(c.insertedRows)/elapsed.Seconds())
+	if c.failedBatches > 0 {
+		fmt.Printf(">>> 写入失败: %d 个批次, %d 行\n", c.failedBatches, c.failedRows)
+		return fmt.Errorf("%d batch(es) failed, %d row(s) not written", c.failedBatches, c.failedRows)
+	}
+	return nil
 }
 
-// loadTableData 加载单表数据
-func loadTableData(db *sql.DB, tableName string, totalRows int, isLarge bool) {
+// loadTableData 加载单表数据，每个批次写入后更新 c
+func loadTableData(db *sql.DB, c *loadCounters, tableName string, totalRows int, isLarge bool) {
 	start := time.Now()
 
 	// 大表优化：设置会话参数加速插入
@@ -347,6 +406,14 @@ func loadTableData(db *sql.DB, tableName string, totalRows int, isLarge bool) {
 	lastPrint := time.Now()
 	printedStart := false
 
+	// -mysql-load-method prepared 时按批次行数缓存本表的预编译语句，表加载完成后释放
+	stmts := map[int]*sql.Stmt{}
+	defer func() {
+		for _, stmt := range stmts {
+			stmt.Close()
+		}
+	}()
+
 	// 大表使用更大的 batch
 	currentBatchSize := batchSize
 	if isLarge {
@@ -362,7 +429,14 @@ func loadTableData(db *sql.DB, tableName string, totalRows int, isLarge bool) {
 			batchRows = totalRows - loaded
 		}
 
-		insertBatch(db, tableName, batchRows, loaded, isLarge)
+		written, err := insertBatch(db, stmts, tableName, batchRows, loaded, isLarge)
+		atomic.AddInt64(&c.completedRows, int64(batchRows))
+		atomic.AddInt64(&c.insertedRows, int64(written))
+		if err != nil {
+			atomic.AddInt64(&c.failedBatches, 1)
+			atomic.AddInt64(&c.failedRows, int64(batchRows-written))
+			log.Printf("批量插入 %s 失败: %v", tableName, err)
+		}
 		loaded += batchRows
 
 		// 大表加载超过1分钟时显示进度
@@ -386,17 +460,18 @@ func loadTableData(db *sql.DB, tableName string, totalRows int, isLarge bool) {
 	}
 }
 
-// insertBatch 批量插入数据
-func insertBatch(db *sql.DB, tableName string, rows int, offset int, isLarge bool) {
+// insertBatch 批量插入数据，返回实际写入的行数
+func insertBatch(db *sql.DB, stmts map[int]*sql.Stmt, tableName string, rows int, offset int, isLarge bool) (int, error) {
 	if rows == 0 {
-		return
+		return 0, nil
 	}
 
 	var sqlPrefix string
 	var placeholders []string
 	var values []interface{}
 
-	baseTime := time.Now()
+	// 每个批次使用独立的随机源，并发导入时生成的数据也与调度顺序无关
+	rng := rand.New(rand.NewSource(batchSeed(tableName, offset)))
 
 	if isLarge {
 		// 大表：包含pkb列（唯一数据）
@@ -420,24 +495,24 @@ func insertBatch(db *sql.DB, tableName string, rows int, offset int, isLarge boo
 			rowNum := offset + i
 			values = append(values,
 				int64(rowNum+1), // pkb (唯一值，从1开始)
-				fmt.Sprintf("varchar1_%d_%d", rowNum, rand.Intn(10000)), // col_varchar_1
-				fmt.Sprintf("varchar2_%d_%s", rowNum, randomString(20)), // col_varchar_2
-				fmt.Sprintf("v3_%d", rowNum),                            // col_varchar_3
-				rand.Intn(1000000),                                      // col_int_1
-				rand.Intn(500000),                                       // col_int_2
-				rand.Intn(100000),                                       // col_int_3
-				rand.Int63n(10000000000),                                // col_bigint_1
-				rand.Int63n(5000000000),                                 // col_bigint_2
-				float64(rand.Int63n(100000000))/10000,                   // col_decimal_1
-				float64(rand.Int63n(10000000))/100,                      // col_decimal_2
-				rand.Float32()*10000,                                    // col_float_1
-				rand.Float64()*100000,                                   // col_double_1
-				baseTime.Add(-time.Duration(rand.Intn(365*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_1
-				baseTime.Add(-time.Duration(rand.Intn(180*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_2
-				baseTime.Add(-time.Duration(rand.Intn(365*24))*time.Hour).Format("2006-01-02"),          // col_date_1
-				fmt.Sprintf("Text content for row %d: %s", rowNum, randomString(50)),                    // col_text_1
-				rand.Intn(128),   // col_tinyint_1
-				rand.Intn(32768), // col_smallint_1
+				fmt.Sprintf("varchar1_%d_%d", rowNum, rng.Intn(10000)),       // col_varchar_1
+				fmt.Sprintf("varchar2_%d_%s", rowNum, randomString(rng, 20)), // col_varchar_2
+				fmt.Sprintf("v3_%d", rowNum),                                 // col_varchar_3
+				rng.Intn(1000000),                                            // col_int_1
+				rng.Intn(500000),                                             // col_int_2
+				rng.Intn(100000),                                             // col_int_3
+				rng.Int63n(10000000000),                                      // col_bigint_1
+				rng.Int63n(5000000000),                                       // col_bigint_2
+				float64(rng.Int63n(100000000))/10000,                         // col_decimal_1
+				float64(rng.Int63n(10000000))/100,                            // col_decimal_2
+				rng.Float32()*10000,                                          // col_float_1
+				rng.Float64()*100000,                                         // col_double_1
+				baseTime.Add(-time.Duration(rng.Intn(365*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_1
+				baseTime.Add(-time.Duration(rng.Intn(180*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_2
+				baseTime.Add(-time.Duration(rng.Intn(365*24))*time.Hour).Format("2006-01-02"),          // col_date_1
+				fmt.Sprintf("Text content for row %d: %s", rowNum, randomString(rng, 50)),              // col_text_1
+				rng.Intn(128),   // col_tinyint_1
+				rng.Intn(32768), // col_smallint_1
 			)
 		}
 	} else {
@@ -462,35 +537,141 @@ func insertBatch(db *sql.DB, tableName string, rows int, offset int, isLarge boo
 			rowNum := offset + i
 			values = append(values,
 				int64(rowNum+1), // pkb (唯一值，从1开始)
-				fmt.Sprintf("varchar1_%d_%d", rowNum, rand.Intn(10000)), // col_varchar_1
-				fmt.Sprintf("varchar2_%d_%s", rowNum, randomString(20)), // col_varchar_2
-				fmt.Sprintf("v3_%d", rowNum),                            // col_varchar_3
-				rand.Intn(1000000),                                      // col_int_1
-				rand.Intn(500000),                                       // col_int_2
-				rand.Intn(100000),                                       // col_int_3
-				rand.Int63n(10000000000),                                // col_bigint_1
-				rand.Int63n(5000000000),                                 // col_bigint_2
-				float64(rand.Int63n(100000000))/10000,                   // col_decimal_1
-				float64(rand.Int63n(10000000))/100,                      // col_decimal_2
-				rand.Float32()*10000,                                    // col_float_1
-				rand.Float64()*100000,                                   // col_double_1
-				baseTime.Add(-time.Duration(rand.Intn(365*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_1
-				baseTime.Add(-time.Duration(rand.Intn(180*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_2
-				baseTime.Add(-time.Duration(rand.Intn(365*24))*time.Hour).Format("2006-01-02"),          // col_date_1
-				fmt.Sprintf("Text content for row %d: %s", rowNum, randomString(50)),                    // col_text_1
-				rand.Intn(128),   // col_tinyint_1
-				rand.Intn(32768), // col_smallint_1
+				fmt.Sprintf("varchar1_%d_%d", rowNum, rng.Intn(10000)),       // col_varchar_1
+				fmt.Sprintf("varchar2_%d_%s", rowNum, randomString(rng, 20)), // col_varchar_2
+				fmt.Sprintf("v3_%d", rowNum),                                 // col_varchar_3
+				rng.Intn(1000000),                                            // col_int_1
+				rng.Intn(500000),                                             // col_int_2
+				rng.Intn(100000),                                             // col_int_3
+				rng.Int63n(10000000000),                                      // col_bigint_1
+				rng.Int63n(5000000000),                                       // col_bigint_2
+				float64(rng.Int63n(100000000))/10000,                         // col_decimal_1
+				float64(rng.Int63n(10000000))/100,                            // col_decimal_2
+				rng.Float32()*10000,                                          // col_float_1
+				rng.Float64()*100000,                                         // col_double_1
+				baseTime.Add(-time.Duration(rng.Intn(365*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_1
+				baseTime.Add(-time.Duration(rng.Intn(180*24))*time.Hour).Format("2006-01-02 15:04:05"), // col_datetime_2
+				baseTime.Add(-time.Duration(rng.Intn(365*24))*time.Hour).Format("2006-01-02"),          // col_date_1
+				fmt.Sprintf("Text content for row %d: %s", rowNum, randomString(rng, 50)),              // col_text_1
+				rng.Intn(128),   // col_tinyint_1
+				rng.Intn(32768), // col_smallint_1
 			)
 		}
 	}
 
-	sqlStr := sqlPrefix + strings.Join(placeholders, ",")
-	_, err := db.Exec(sqlStr, values...)
+	return writeBatch(db, stmts, tableName, sqlPrefix, placeholders, values)
+}
+
+func validLoadMethod(m string) bool {
+	for _, v := range loadMethods {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBatch 按 -mysql-load-method 写入一批数据，values 按行依次排列，每行 benchColumnCount 列
+// 返回实际写入的行数：INSERT 与事务要么整批写入要么整批失败，LOAD DATA 可能只写入部分行
+func writeBatch(db *sql.DB, stmts map[int]*sql.Stmt, tableName, sqlPrefix string, placeholders []string, values []interface{}) (int, error) {
+	rows := len(placeholders)
+	switch loadMethod {
+	case methodPrepared:
+		stmt, ok := stmts[rows]
+		if !ok {
+			var err error
+			if stmt, err = db.Prepare(sqlPrefix + strings.Join(placeholders, ",")); err != nil {
+				return 0, err
+			}
+			stmts[rows] = stmt
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return 0, err
+		}
+		return rows, nil
+	case methodTx:
+		tx, err := db.Begin()
+		if err != nil {
+			return 0, err
+		}
+		stmt, err := tx.Prepare(sqlPrefix + placeholders[0])
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		defer stmt.Close()
+		for i := 0; i < rows; i++ {
+			if _, err := stmt.Exec(values[i*benchColumnCount : (i+1)*benchColumnCount]...); err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return rows, nil
+	case methodLoadData:
+		return loadDataInfile(db, tableName, rows, values)
+	default:
+		if _, err := db.Exec(sqlPrefix+strings.Join(placeholders, ","), values...); err != nil {
+			return 0, err
+		}
+		return rows, nil
+	}
+}
+
+// 每个批次注册一个唯一名称的 Reader 处理器
+var readerSeq int64
+
+// loadDataInfile 以 LOAD DATA LOCAL INFILE 写入一批数据（制表符分隔、反斜杠转义）
+// LOCAL 模式下主键冲突与数据错误只产生警告并跳过该行，按影响行数返回实际写入的行数并报告被跳过的行
+func loadDataInfile(db *sql.DB, tableName string, rows int, values []interface{}) (int, error) {
+	var buf bytes.Buffer
+	for i, v := range values {
+		if i%benchColumnCount != 0 {
+			buf.WriteByte('\t')
+		}
+		switch x := v.(type) {
+		case int:
+			buf.WriteString(strconv.Itoa(x))
+		case int64:
+			buf.WriteString(strconv.FormatInt(x, 10))
+		case float32:
+			buf.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+		case float64:
+			buf.WriteString(strconv.FormatFloat(x, 'g', -1, 64))
+		case string:
+			buf.WriteString(loadDataEscaper.Replace(x))
+		default:
+			return 0, fmt.Errorf("unsupported value type %T", v)
+		}
+		if i%benchColumnCount == benchColumnCount-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	data := buf.Bytes()
+	name := fmt.Sprintf("demo2-%d", atomic.AddInt64(&readerSeq, 1))
+	mysql.RegisterReaderHandler(name, func() io.Reader { return bytes.NewReader(data) })
+	defer mysql.DeregisterReaderHandler(name)
+
+	res, err := db.Exec(fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 (%s)",
+		name, tableName, benchColumns))
 	if err != nil {
-		log.Printf("批量插入 %s 失败: %v", tableName, err)
+		return 0, err
 	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n < int64(rows) {
+		return int(n), fmt.Errorf("LOAD DATA skipped %d of %d row(s) (duplicate key or invalid data)", int64(rows)-n, rows)
+	}
+	return rows, nil
 }
 
+// LOAD DATA 默认 FIELDS ESCAPED BY '\\'，需要转义反斜杠、制表符与换行
+var loadDataEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
 // executeDDLOperations 执行DDL操作
 func executeDDLOperations(db *sql.DB) {
 	// 对大表执行DDL操作（添加新列 -> 更新 -> 设置主键）
@@ -563,12 +744,19 @@ func executeDDLOperations(db *sql.DB) {
 	fmt.Printf("\n>>> 小表DDL操作完成: 成功 %d 张，失败 %d 张，耗时: %v\n", successCount, failCount, time.Since(smallTableStart))
 }
 
+// batchSeed 由全局种子、表名与批次起始行号派生批次的随机种子
+func batchSeed(tableName string, offset int) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%d", seed, tableName, offset)
+	return int64(h.Sum64())
+}
+
 // randomString 生成随机字符串
-func randomString(length int) string {
+func randomString(rng *rand.Rand, length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+		b[i] = charset[rng.Intn(len(charset))]
 	}
 	return string(b)
 }